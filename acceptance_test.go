@@ -5,7 +5,10 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"talisman/detector"
+	"talisman/gitrepo"
 	"talisman/prompt"
 	"testing"
 
@@ -20,7 +23,7 @@ const awsAccessKeyIDExample string = "accessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEX
 const talismanRCDataWithIgnoreDetectorWithFilename = `
 fileignoreconfig:
 - filename: private.pem
-  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c
+  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c0
   ignore_detectors: [filename]
 `
 
@@ -32,7 +35,7 @@ scopeconfig:
 const talismanRCDataWithIgnoreDetectorWithFilecontent = `
 fileignoreconfig:
 - filename: private.pem
-  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c
+  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c0
   ignore_detectors: [filecontent]
 `
 
@@ -94,6 +97,80 @@ func TestAddingSecretKeyShouldExitZeroIfPEMFileIsIgnored(t *testing.T) {
 	})
 }
 
+func TestAddingSecretKeyShouldWriteAJSONReportWhenAReportDirectoryIsGiven(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		reportDirectory, _ := ioutil.TempDir("", "talisman-report")
+		defer os.RemoveAll(reportDirectory)
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, options{githook: PrePush, reportdirectory: reportDirectory}))
+		report, err := ioutil.ReadFile(filepath.Join(reportDirectory, "talisman_reports", "data", "report.json"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(report), `"filename":"private.pem"`)
+	})
+}
+
+func TestScanningARangeShouldOnlyScanTheCommitsOfTheRangeAndWriteAReport(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		secretCommit := git.LatestCommit()
+		git.CreateFileWithContents("safe.txt", "nothing to see here")
+		git.AddAndcommit("*", "add a safe file")
+		reportDirectory, _ := ioutil.TempDir("", "talisman-report")
+		defer os.RemoveAll(reportDirectory)
+
+		assert.Equal(t, 0, runTalismanWithOptions(git, options{revisionRange: "HEAD~1..HEAD", reportdirectory: reportDirectory}), "Expected run() to return 0 as the private key was added before the range")
+		assert.Equal(t, 1, runTalismanWithOptions(git, options{revisionRange: "HEAD~2..HEAD", reportdirectory: reportDirectory}), "Expected run() to return 1 as the private key was added in the range")
+		report, err := ioutil.ReadFile(filepath.Join(reportDirectory, "talisman_reports", "data", "report.json"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(report), `"filename":"private.pem"`)
+		assert.Contains(t, string(report), secretCommit)
+	})
+}
+
+func TestScanningAnUnknownRangeShouldExitOneWithoutReportingAnyFile(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		reportDirectory, _ := ioutil.TempDir("", "talisman-report")
+		defer os.RemoveAll(reportDirectory)
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, options{revisionRange: "no-such-ref..HEAD", reportdirectory: reportDirectory}), "Expected run() to return 1 as the range could not be read")
+		_, err := os.Stat(filepath.Join(reportDirectory, "talisman_reports"))
+		assert.True(t, os.IsNotExist(err), "Expected no report, as there are no files to report on")
+	})
+}
+
+func TestAddingSecretKeyShouldExitOneWhenItIsPushedAlongWithAnotherRef(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		stdin := strings.NewReader(fmt.Sprintf("refs/heads/master %s refs/heads/master %s\nrefs/heads/feature %s refs/heads/feature %s\n",
+			git.LatestCommit(), git.LatestCommit(), git.LatestCommit(), git.EarliestCommit()))
+
+		wd, _ := os.Getwd()
+		os.Chdir(git.GetRoot())
+		defer func() { os.Chdir(wd) }()
+		assert.Equal(t, 1, run(stdin, options{githook: PrePush}, prompt.NewPromptContext(false, prompt.NewPrompt())), "Expected run() to return 1 as the second ref adds a pem file")
+	})
+}
+
+func TestPushingASecretThatALaterCommitRemovesShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("config.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "add aws key")
+		git.OverwriteFileContent("config.txt", "accessKey=${AWS_SECRET_ACCESS_KEY}")
+		git.AddAndcommit("*", "read aws key from the environment")
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as the key is pushed in the history, even though the working tree no longer has it")
+	})
+}
+
 func TestAddingSecretKeyShouldExitOneIfPEMFileIsPresentInTheGitHistory(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		_options := options{
@@ -215,6 +292,18 @@ func TestAddingSecretKeyShouldExitOneIfFileIsNotWithinConfiguredScope(t *testing
 	})
 }
 
+func TestAddingSecretsShouldExitZeroIfFilesAreWithinACustomScope(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("fixtures/aws.txt", awsAccessKeyIDExample)
+		git.CreateFileWithContents("Cargo.lock", awsAccessKeyIDExample)
+		git.CreateFileWithContents(".talismanrc", "scopeconfig:\n- scope: rust\n- scope: fixtures\n  paths: [fixtures/]\n  ignore_detectors: [filecontent]\n")
+		git.AddAndcommit("*", "add fixtures and lock file")
+
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 as the secrets are within the rust and fixtures scopes")
+	})
+}
+
 func TestAddingSecretKeyShouldExitOneIfFileNameIsSensitiveButOnlyFilecontentDetectorWasIgnored(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		git.SetupBaselineFiles("simple-file")
@@ -226,6 +315,86 @@ func TestAddingSecretKeyShouldExitOneIfFileNameIsSensitiveButOnlyFilecontentDete
 	})
 }
 
+func TestShouldExitOneWhenALineIsAddedToAPrivateKeyThatWasAlreadyCommitted(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		_options := options{
+			debug:   false,
+			githook: PreCommit,
+		}
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("key.txt", "BEGIN RSA PRIVATE KEY\n", "END RSA PRIVATE KEY\n")
+		git.AddAndcommit("*", "add an empty key")
+
+		git.OverwriteFileContent("key.txt", "BEGIN RSA PRIVATE KEY\n", "keycontent\n", "END RSA PRIVATE KEY\n")
+		git.Add("*")
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the added line is part of a private key")
+	})
+}
+
+func TestCommitMsgHookShouldExitOneWhenTheMessageHasASecret(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("message.txt", "Connect to the database\n\npassword=somepassword\n")
+		_options := options{
+			debug:       false,
+			githook:     CommitMsg,
+			messageFile: "message.txt",
+		}
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the commit message has a password")
+	})
+}
+
+func TestCommitMsgHookShouldExitZeroWhenTheMessageIsIgnored(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("message.txt", "Connect to the database\n\npassword=somepassword\n")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: COMMIT_EDITMSG\n  ignore_detectors: [filecontent]\n")
+		_options := options{
+			debug:       false,
+			githook:     CommitMsg,
+			messageFile: "message.txt",
+		}
+
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as commit messages are ignored in .talismanrc")
+	})
+}
+
+func TestPrePushHookShouldOnlyScanCommitMessagesWhenAskedTo(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("safe.txt", "nothing to see here")
+		git.AddAndcommit("safe.txt", "Set password=somepassword")
+
+		assert.Equal(t, 0, runTalismanWithOptions(git, options{githook: PrePush}), "Expected run() to return 0 as commit messages are not scanned by default")
+		assert.Equal(t, 1, runTalismanWithOptions(git, options{githook: PrePush, commitMessages: true}), "Expected run() to return 1 as a pushed commit message has a password")
+	})
+}
+
+func TestPrePushHookShouldOnlyScanCommitsThatARemoteHasWhenAskedTo(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		git.ExecCommand("git", "update-ref", "refs/remotes/origin/master", git.LatestCommit())
+		git.CreateFileWithContents("safe.txt", "nothing to see here")
+		git.AddAndcommit("*", "add safe.txt")
+		ref := NewPushedRef("refs/heads/feature", git.LatestCommit(), "refs/heads/feature", EmptySha)
+
+		wd, _ := os.Getwd()
+		os.Chdir(git.GetRoot())
+		defer func() { os.Chdir(wd) }()
+		unpushed, err := NewPrePushHook(false, ref).GetRepoAdditions()
+		assert.NoError(t, err)
+		all, err := NewPrePushHook(true, ref).GetRepoAdditions()
+		assert.NoError(t, err)
+
+		assert.Equal(t, []gitrepo.FilePath{"safe.txt"}, pathsOf(unpushed), "Expected the commits that origin has to be left out")
+		assert.Contains(t, pathsOf(all), gitrepo.FilePath("private.pem"), "Expected every commit of the ref to be scanned with all commits")
+	})
+}
+
 func TestStagingSecretKeyShouldExitOneWhenPreCommitFlagIsSet(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		git.SetupBaselineFiles("simple-file")
@@ -241,6 +410,18 @@ func TestStagingSecretKeyShouldExitOneWhenPreCommitFlagIsSet(t *testing.T) {
 	})
 }
 
+func TestMovingAnIgnoredSecretToAPathThatIsNotIgnoredShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("cfg/prod.yml", awsAccessKeyIDExample)
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: cfg/prod.yml\n  ignore_detectors: [filecontent]\n")
+		git.AddAndcommit("*", "add ignored production config")
+		git.ExecCommand("git", "mv", "cfg/prod.yml", "app.yml")
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, options{githook: PreCommit}), "Expected run() to return 1 as the secret is no longer ignored at its new path")
+	})
+}
+
 func TestPatternFindsSecretKey(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		_options := options{
@@ -283,6 +464,204 @@ func TestPatternFindsSecretInNestedFile(t *testing.T) {
 	})
 }
 
+func TestInvalidTalismanRCShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: simple-file\n  ignore_detectors: [filenames]\n")
+		git.AddAndcommit("*", "add talismanrc")
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 and fail as the .talismanrc is invalid")
+	})
+}
+
+func TestRCValidateShouldReportProblemsInTalismanRC(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: simple-file\n  ignore_detectors: [filenames]\n- filename: deleted-file\n")
+
+		output, status := runRCCommand(git, "validate")
+
+		assert.Equal(t, 1, status, "Expected rc validate to fail as the .talismanrc is invalid")
+		assert.Equal(t, ".talismanrc:2: error: unknown detector \"filenames\" in ignore_detectors of simple-file, expected one of filecontent, filename, filesize\n"+
+			".talismanrc:4: warning: deleted-file does not match any tracked file\n", output)
+	})
+}
+
+func TestRCValidateShouldAcceptValidTalismanRC(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", talismanRCDataWithScopeAsGo)
+
+		output, status := runRCCommand(git, "validate")
+
+		assert.Equal(t, 0, status, "Expected rc validate to pass as the .talismanrc is valid")
+		assert.Equal(t, ".talismanrc is valid\n", output)
+	})
+}
+
+func TestRCPruneShouldRemoveStaleEntriesOnceConfirmed(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n# still needed\n- filename: simple-file\n  ignore_detectors: [filename]\n# gone\n- filename: deleted-file\n  ignore_detectors: [filename]\n")
+
+		output, status := runRCCommandWithAnswer(git, true, "prune")
+
+		assert.Equal(t, 0, status, "Expected rc prune to succeed")
+		assert.Equal(t, ".talismanrc: deleted-file no longer matches any file\nUpdated .talismanrc\n", output)
+		assert.Equal(t, "fileignoreconfig:\n# still needed\n- filename: simple-file\n  ignore_detectors: [filename]\n", string(git.FileContents(".talismanrc")))
+	})
+}
+
+func TestRCPruneShouldLeaveTalismanRCAloneUnlessConfirmed(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		talismanRC := "fileignoreconfig:\n- filename: deleted-file\n  ignore_detectors: [filename]\n"
+		git.CreateFileWithContents(".talismanrc", talismanRC)
+
+		output, status := runRCCommandWithAnswer(git, false, "prune")
+
+		assert.Equal(t, 0, status, "Expected rc prune to succeed")
+		assert.Equal(t, ".talismanrc: deleted-file no longer matches any file\nNo changes made\n", output)
+		assert.Equal(t, talismanRC, string(git.FileContents(".talismanrc")))
+	})
+}
+
+func TestRCUpdateShouldRecalculateStaleChecksums(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCDataWithIgnoreDetectorWithFilename)
+		git.AddAndcommit("*", "add private key")
+
+		output, status := runRCCommandWithAnswer(git, true, "update")
+
+		assert.Equal(t, 0, status, "Expected rc update to succeed")
+		assert.Equal(t, ".talismanrc: the checksum of private.pem no longer matches its content\nUpdated .talismanrc\n", output)
+		git.AddAndcommit(".talismanrc", "update checksums")
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 as the private key is ignored with an up to date checksum")
+	})
+}
+
+func TestRCMigrateShouldConvertTalismanIgnoreIntoTalismanRC(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanignore", "private.pem # ignore:filename\n")
+		git.AddAndcommit("*", "add private key ignored the legacy way")
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as .talismanignore is no longer read")
+
+		output, status := runRCCommandWithAnswer(git, true, "migrate")
+
+		checksum := "1db800b79e6e9695adc451f77be974dc47bcd84d42873560d7767bfca30db8b1"
+		assert.Equal(t, 0, status, "Expected rc migrate to succeed")
+		assert.Equal(t, "--- .talismanrc\n+++ .talismanrc\n+fileignoreconfig:\n+- filename: private.pem\n+  checksum: "+checksum+"\n+  ignore_detectors:\n+  - filename\n"+
+			"Updated .talismanrc. .talismanignore is no longer read, and can be removed\n", output)
+		git.AddAndcommit(".talismanrc", "migrate ignores")
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 as the ignores were migrated to .talismanrc")
+	})
+}
+
+func runRCCommand(git *git_testing.GitTesting, args ...string) (string, int) {
+	wd, _ := os.Getwd()
+	os.Chdir(git.GetRoot())
+	defer func() { os.Chdir(wd) }()
+	output := &strings.Builder{}
+	status := NewRCCommand(output).Run(args)
+	return output.String(), status
+}
+
+func runRCCommandWithAnswer(git *git_testing.GitTesting, answer bool, args ...string) (string, int) {
+	wd, _ := os.Getwd()
+	os.Chdir(git.GetRoot())
+	defer func() { os.Chdir(wd) }()
+	output := &strings.Builder{}
+	command := NewRCCommand(output)
+	command.prompt = answeringPrompt(answer)
+	status := command.Run(args)
+	return output.String(), status
+}
+
+//answeringPrompt confirms every question with the same answer
+type answeringPrompt bool
+
+func (p answeringPrompt) Confirm(string) bool {
+	return bool(p)
+}
+
+func (p answeringPrompt) Input(message string, defaultValue string) string {
+	return defaultValue
+}
+
+func (p answeringPrompt) Select(message string, options []string, defaultOption string) string {
+	return defaultOption
+}
+
+func TestNestedTalismanRCShouldOnlyIgnoreFilesInItsDirectory(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("team/private.pem", "secret")
+		git.CreateFileWithContents("team/.talismanrc", "fileignoreconfig:\n- filename: private.pem\n  ignore_detectors: [filename]\n")
+		git.AddAndcommit("*", "add private key owned by team")
+
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 and pass as pem file was ignored by the team")
+
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key outside of team")
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 and fail as pem file outside the team directory was not ignored")
+	})
+}
+
+func TestPolicyShouldForbidIgnoringFilesInTheRepository(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		policyFile, _ := ioutil.TempFile("", "policy.talismanrc")
+		defer os.Remove(policyFile.Name())
+		policyFile.WriteString("forbidden_ignores: [\"*.pem\"]\n")
+		policyFile.Close()
+		os.Setenv(detector.PolicyFileEnvVar, policyFile.Name())
+		defer os.Unsetenv(detector.PolicyFileEnvVar)
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCDataWithIgnoreDetectorWithFilename)
+		git.AddAndcommit("*", "add private key")
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as the policy forbids ignoring pem files")
+	})
+}
+
+func TestIgnoresWithoutAReasonShouldExitOneWhenAReasonIsRequired(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", "require_ignore_reason: true"+talismanRCDataWithIgnoreDetectorWithFilename)
+		git.AddAndcommit("*", "add private key")
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as the ignore of the pem file has no reason")
+	})
+}
+
+func TestStagingSecretWhileTheWorkingTreeStaysCleanShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		reviewed := gitrepo.NewAddition("config.txt", []byte("reviewed content"))
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", fmt.Sprintf("fileignoreconfig:\n- filename: config.txt\n  checksum: %s\n", detector.ContentChecksum([]gitrepo.Addition{reviewed})))
+		git.AddAndcommit("*", "add talismanrc")
+		_options := options{
+			debug:   false,
+			githook: PreCommit,
+		}
+
+		git.CreateFileWithContents("config.txt", "reviewed content")
+		git.Add("config.txt")
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the staged content was reviewed")
+
+		git.OverwriteFileContent("config.txt", awsAccessKeyIDExample)
+		git.Add("config.txt")
+		git.OverwriteFileContent("config.txt", "reviewed content")
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the staged content contains a secret")
+	})
+}
+
 func runTalisman(git *git_testing.GitTesting) int {
 	_options := options{
 		debug:   false,
@@ -300,6 +679,14 @@ func runTalismanWithOptions(git *git_testing.GitTesting, _options options) int {
 	return run(mockStdIn(git.EarliestCommit(), git.LatestCommit()), _options, promptContext)
 }
 
+func pathsOf(additions []gitrepo.Addition) []gitrepo.FilePath {
+	var paths []gitrepo.FilePath
+	for _, addition := range additions {
+		paths = append(paths, addition.Path)
+	}
+	return paths
+}
+
 type Operation func(dirName string)
 
 func withNewTmpDirNamed(dirName string, operation Operation) {
@@ -2,10 +2,10 @@ package checksumcalculator
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"talisman/detector"
 	"talisman/gitrepo"
-	"talisman/utility"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -24,13 +24,16 @@ func NewChecksumCalculator(patterns []string) *ChecksumCalculator {
 func (cc *ChecksumCalculator) SuggestTalismanRC() string {
 	wd, _ := os.Getwd()
 	repo := gitrepo.RepoLocatedAt(wd)
-	gitTrackedFilesAsAdditions := repo.TrackedFilesAsAdditions()
-	//Adding staged files for calculation
-	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, repo.StagedAdditions()...)
+	//Checksums are calculated from the staged content, which is what talisman scans
+	indexedAdditions, err := repo.IndexedAdditions()
+	if err != nil {
+		log.Printf("error listing the files in the index: %v", err)
+		return ""
+	}
 	var fileIgnoreConfigs []detector.FileIgnoreConfig
 	result := ""
 	for _, pattern := range cc.fileNamePatterns {
-		collectiveChecksum := cc.calculateCollectiveChecksumForPattern(pattern, gitTrackedFilesAsAdditions)
+		collectiveChecksum := cc.calculateCollectiveChecksumForPattern(pattern, indexedAdditions)
 		if collectiveChecksum != "" {
 			fileIgnoreConfig := detector.FileIgnoreConfig{FileName: pattern, Checksum: collectiveChecksum, IgnoreDetectors: []string{}}
 			fileIgnoreConfigs = append(fileIgnoreConfigs, fileIgnoreConfig)
@@ -46,17 +49,14 @@ func (cc *ChecksumCalculator) SuggestTalismanRC() string {
 }
 
 func (cc *ChecksumCalculator) calculateCollectiveChecksumForPattern(fileNamePattern string, additions []gitrepo.Addition) string {
-	var patternpaths []string
-	currentCollectiveChecksum := ""
+	var matching []gitrepo.Addition
 	for _, addition := range additions {
 		if addition.Matches(fileNamePattern) {
-			patternpaths = append(patternpaths, string(addition.Path))
+			matching = append(matching, addition)
 		}
 	}
-	// Calculate current collective checksum
-	patternpaths = utility.UniqueItems(patternpaths)
-	if len(patternpaths) != 0 {
-		currentCollectiveChecksum = utility.CollectiveSHA256Hash(patternpaths)
+	if len(matching) == 0 {
+		return ""
 	}
-	return currentCollectiveChecksum
+	return detector.ContentChecksum(matching)
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	"talisman/gitrepo"
+)
+
+//scissorsLine matches the line below which git leaves out the rest of the message, such as the diff that git commit --verbose shows
+var scissorsLine = regexp.MustCompile(`(?m)^. -{24} >8 -{24}$`)
+
+//CommitMsgHook scans the message of the commit being made, which git passes to the commit-msg hook as a file
+type CommitMsgHook struct {
+	messageFile string
+}
+
+//NewCommitMsgHook returns a CommitMsgHook for the message held in messageFile
+func NewCommitMsgHook(messageFile string) *CommitMsgHook {
+	return &CommitMsgHook{messageFile}
+}
+
+//GetRepoAdditions returns the message being committed as an addition at gitrepo.CommitMessagePath.
+//The message is read when it is scanned, so a message file that cannot be read is reported like any other file.
+func (c *CommitMsgHook) GetRepoAdditions() ([]gitrepo.Addition, error) {
+	message := gitrepo.NewLazyAddition(gitrepo.CommitMessagePath, nil, c.openMessage)
+	message.CommitMessage = true
+	return []gitrepo.Addition{message}, nil
+}
+
+func (c *CommitMsgHook) openMessage() (io.ReadCloser, error) {
+	message, err := ioutil.ReadFile(c.messageFile)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(withoutScissoredLines(message))), nil
+}
+
+//withoutScissoredLines leaves out the scissors line of the message and everything below it, as git does
+func withoutScissoredLines(message []byte) []byte {
+	if location := scissorsLine.FindIndex(message); location != nil {
+		return message[:location[0]]
+	}
+	return message
+}
@@ -0,0 +1,111 @@
+package detector
+
+//ahoCorasick is an automaton that finds every occurrence of a fixed set of patterns in a single pass over a text.
+//See https://en.wikipedia.org/wiki/Aho%E2%80%93Corasick_algorithm for reference
+type ahoCorasick struct {
+	nodes []acNode
+}
+
+type acEdge struct {
+	label byte
+	node  int32
+}
+
+type acNode struct {
+	edges []acEdge
+	fail  int32
+	//output is the index of the pattern ending at this node, or -1
+	output int32
+	//outputLink is the closest node along the failure links that has an output, or -1
+	outputLink int32
+	depth      int32
+}
+
+//newAhoCorasick builds an automaton for the supplied patterns.
+//Matches are reported using the index of the pattern in the supplied slice, so patterns are expected to be unique.
+func newAhoCorasick(patterns []string) *ahoCorasick {
+	ac := &ahoCorasick{nodes: []acNode{{fail: 0, output: -1, outputLink: -1}}}
+	for index, pattern := range patterns {
+		ac.insert(pattern, int32(index))
+	}
+	ac.link()
+	return ac
+}
+
+func (ac *ahoCorasick) insert(pattern string, index int32) {
+	if pattern == "" {
+		return
+	}
+	current := int32(0)
+	for i := 0; i < len(pattern); i++ {
+		next := ac.child(current, pattern[i])
+		if next < 0 {
+			next = int32(len(ac.nodes))
+			ac.nodes = append(ac.nodes, acNode{output: -1, outputLink: -1, depth: ac.nodes[current].depth + 1})
+			ac.nodes[current].edges = append(ac.nodes[current].edges, acEdge{pattern[i], next})
+		}
+		current = next
+	}
+	ac.nodes[current].output = index
+}
+
+//link computes the failure and output links breadth first, so that a node's links are known before its children's.
+func (ac *ahoCorasick) link() {
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, edge := range ac.nodes[0].edges {
+		queue = append(queue, edge.node)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range ac.nodes[current].edges {
+			fail := ac.nodes[current].fail
+			for fail > 0 && ac.child(fail, edge.label) < 0 {
+				fail = ac.nodes[fail].fail
+			}
+			if next := ac.child(fail, edge.label); next >= 0 && next != edge.node {
+				fail = next
+			} else {
+				fail = 0
+			}
+			child := &ac.nodes[edge.node]
+			child.fail = fail
+			if ac.nodes[fail].output >= 0 {
+				child.outputLink = fail
+			} else {
+				child.outputLink = ac.nodes[fail].outputLink
+			}
+			queue = append(queue, edge.node)
+		}
+	}
+}
+
+func (ac *ahoCorasick) child(node int32, label byte) int32 {
+	for _, edge := range ac.nodes[node].edges {
+		if edge.label == label {
+			return edge.node
+		}
+	}
+	return -1
+}
+
+//findAll calls found for every occurrence of every pattern in the text, with the pattern index and the offset the occurrence starts at
+func (ac *ahoCorasick) findAll(text string, found func(pattern int, start int)) {
+	current := int32(0)
+	for i := 0; i < len(text); i++ {
+		next := ac.child(current, text[i])
+		for next < 0 && current > 0 {
+			current = ac.nodes[current].fail
+			next = ac.child(current, text[i])
+		}
+		if next < 0 {
+			next = 0
+		}
+		current = next
+		for output := current; output > 0; output = ac.nodes[output].outputLink {
+			if ac.nodes[output].output >= 0 {
+				found(int(ac.nodes[output].output), i+1-int(ac.nodes[output].depth))
+			}
+		}
+	}
+}
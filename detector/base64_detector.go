@@ -19,6 +19,13 @@ func NewBase64Detector() *Base64Detector {
 	return &bd
 }
 
+//withWordCheck returns a copy of the detector that consults the supplied dictionaries, leaving the detector itself unchanged
+func (bd *Base64Detector) withWordCheck(wordCheck *WordCheck) *Base64Detector {
+	copy := *bd
+	copy.wordCheck = wordCheck
+	return &copy
+}
+
 func (bd *Base64Detector) initBase64Map() {
 	bd.base64Map = map[string]bool{}
 	for i := 0; i < len(BASE64_CHARS); i++ {
@@ -1,6 +1,8 @@
 package detector
 
 import (
+	"fmt"
+	"sort"
 	"talisman/gitrepo"
 	"talisman/utility"
 )
@@ -10,24 +12,58 @@ type ChecksumCompare struct {
 	ignoreConfig TalismanRCIgnore
 }
 
-//NewChecksumCompare returns new instance of the ChecksumCompare
+//NewChecksumCompare returns new instance of the ChecksumCompare.
+//The files of the index that the config was given, and that are not among the additions, are checksummed along with the additions.
 func NewChecksumCompare(gitAdditions []gitrepo.Addition, talismanRCIgnoreConfig TalismanRCIgnore) *ChecksumCompare {
-	cc := ChecksumCompare{additions: gitAdditions, ignoreConfig: talismanRCIgnoreConfig}
+	cc := ChecksumCompare{additions: withIndexedFiles(gitAdditions, talismanRCIgnoreConfig.indexed), ignoreConfig: talismanRCIgnoreConfig}
 	return &cc
 }
 
-func (cc *ChecksumCompare) IsScanNotRequired(addition gitrepo.Addition) bool {
-	currentCollectiveChecksum := utility.CollectiveSHA256Hash([]string{string(addition.Path)})
-	declaredCheckSum := ""
-	for _, ignore := range cc.ignoreConfig.FileIgnoreConfig {
-		if addition.Matches(ignore.FileName) {
-			currentCollectiveChecksum = utility.CollectiveSHA256Hash([]string{ignore.FileName})
-			declaredCheckSum = ignore.Checksum
+//withIndexedFiles returns the additions along with the indexed files that none of them has the path of
+func withIndexedFiles(additions []gitrepo.Addition, indexed []gitrepo.Addition) []gitrepo.Addition {
+	if len(indexed) == 0 {
+		return additions
+	}
+	paths := map[gitrepo.FilePath]bool{}
+	for _, addition := range additions {
+		paths[addition.Path] = true
+	}
+	result := append([]gitrepo.Addition{}, additions...)
+	for _, file := range indexed {
+		if !paths[file.Path] {
+			result = append(result, file)
 		}
-
 	}
-	return currentCollectiveChecksum == declaredCheckSum
+	return result
+}
 
+//IsScanNotRequired answers if the addition matches an ignore whose checksum is still valid, and may be ignored by the named detector.
+//Checksums are calculated from the content being scanned, rather than the working tree.
+//Ignores of a nested .talismanrc are checksummed relative to its directory.
+//An ignore that names detectors only applies to those detectors.
+func (cc *ChecksumCompare) IsScanNotRequired(addition gitrepo.Addition, detectorName string) bool {
+	if !cc.ignoreConfig.mayBeIgnored(addition, detectorName) {
+		return false
+	}
+	config, relative := cc.ignoreConfig.configFor(addition)
+	declaredCheckSum := ""
+	var ignore *FileIgnoreConfig
+	for i := range config.FileIgnoreConfig {
+		if !isEmptyString(config.FileIgnoreConfig[i].FileName) && !config.FileIgnoreConfig[i].isExpired(now()) && config.FileIgnoreConfig[i].pattern().Matches(relative) {
+			ignore, declaredCheckSum = nil, ""
+			if !config.FileIgnoreConfig[i].pattern().IsNegation() {
+				ignore = &config.FileIgnoreConfig[i]
+				declaredCheckSum = ignore.Checksum
+			}
+		}
+	}
+	if ignore == nil || declaredCheckSum == "" {
+		return false
+	}
+	if len(ignore.IgnoreDetectors) > 0 && !contains(ignore.IgnoreDetectors, detectorName) {
+		return false
+	}
+	return cc.contentChecksum(config, ignore.FileName, &addition) == declaredCheckSum
 }
 
 //FilterIgnoresBasedOnChecksums filters the file ignores from the TalismanRCIgnore which doesn't have any checksum value or having mismatched checksum value from the .talsimanrc
@@ -45,18 +81,130 @@ func (cc *ChecksumCompare) FilterIgnoresBasedOnChecksums() TalismanRCIgnore {
 	return rc
 }
 
+//StaleIgnore is a fileignoreconfig entry that no longer matches any file, or whose checksum no longer matches the content of the files it matches
+type StaleIgnore struct {
+	//Index is the position of the entry in the fileignoreconfig of its .talismanrc
+	Index  int
+	Ignore FileIgnoreConfig
+	//Checksum is the checksum of the content of the files that the entry matches, or empty if it matches no file
+	Checksum string
+}
+
+//MatchesNoFile answers if the entry no longer matches any file
+func (s StaleIgnore) MatchesNoFile() bool {
+	return s.Checksum == ""
+}
+
+func (s StaleIgnore) String() string {
+	if s.MatchesNoFile() {
+		return fmt.Sprintf("%s no longer matches any file", s.Ignore.FileName)
+	}
+	return fmt.Sprintf("the checksum of %s no longer matches its content", s.Ignore.FileName)
+}
+
+//StaleIgnores returns the fileignoreconfig entries of the config that are stale, relative to the additions.
+//The config is either the root .talismanrc or a nested one. Entries without a checksum are only stale if they match no file.
+func (cc *ChecksumCompare) StaleIgnores(config TalismanRCIgnore) []StaleIgnore {
+	var stale []StaleIgnore
+	for i, ignore := range config.FileIgnoreConfig {
+		if isEmptyString(ignore.FileName) {
+			continue
+		}
+		matching := cc.matching(config, ignore.FileName, nil)
+		if len(matching) == 0 {
+			stale = append(stale, StaleIgnore{Index: i, Ignore: ignore})
+			continue
+		}
+		if checksum := ContentChecksum(matching); ignore.Checksum != "" && !ignore.pattern().IsNegation() && ignore.Checksum != checksum {
+			stale = append(stale, StaleIgnore{Index: i, Ignore: ignore, Checksum: checksum})
+		}
+	}
+	return stale
+}
+
+//ReportLegacyChecksums warns about the ignores whose checksum was calculated from the working tree,
+//which differs from the content being scanned. Such checksums are no longer honoured, and need to be calculated again.
+func (cc *ChecksumCompare) ReportLegacyChecksums(result *DetectionResults) {
+	for _, config := range append([]TalismanRCIgnore{cc.ignoreConfig}, cc.ignoreConfig.Nested...) {
+		source := config.Source
+		if source == "" {
+			source = DefaultRCFileName
+		}
+		for _, ignore := range config.FileIgnoreConfig {
+			matching := cc.matching(config, ignore.FileName, nil)
+			if ignore.Checksum == "" || isEmptyString(ignore.FileName) || ignore.pattern().IsNegation() || len(matching) == 0 {
+				continue
+			}
+			if ContentChecksum(matching) == ignore.Checksum {
+				continue
+			}
+			if utility.CollectiveSHA256HashIn(config.Directory, sortedPaths(matching)) == ignore.Checksum {
+				result.Warn(gitrepo.FilePath(source), "filecontent", fmt.Sprintf("The checksum of %s was calculated from the working tree, which differs from the content being scanned, so it is no longer honoured. Calculate it again with talisman --checksum %s", ignore.FileName, ignore.FileName), []string{})
+			}
+		}
+	}
+}
+
+//contentChecksum returns the collective checksum of the content being scanned for the additions that match the pattern of an ignore of the config.
+//The supplied addition, if any, takes the place of the other additions with the same path.
+func (cc *ChecksumCompare) contentChecksum(config TalismanRCIgnore, pattern string, addition *gitrepo.Addition) string {
+	return ContentChecksum(cc.matching(config, pattern, addition))
+}
+
+//matching returns the additions that match the pattern of an ignore of the config, named relative to the directory of the config.
+//The additions that a negation matches are returned as well.
+func (cc *ChecksumCompare) matching(config TalismanRCIgnore, pattern string, addition *gitrepo.Addition) []gitrepo.Addition {
+	var result []gitrepo.Addition
+	for _, candidate := range cc.additions {
+		if addition != nil && candidate.Path == addition.Path {
+			continue
+		}
+		if relative, ok := config.relativeAddition(candidate); ok && gitrepo.NewPattern(pattern).Matches(relative) {
+			result = append(result, relative)
+		}
+	}
+	if addition != nil {
+		if relative, ok := config.relativeAddition(*addition); ok {
+			result = append(result, relative)
+		}
+	}
+	return result
+}
+
+//ContentChecksum returns the collective checksum of the content of the additions, just like talisman --checksum calculates it.
+//The additions are sorted by their path, and an addition whose path occurs more than once is only taken into account the first time.
+func ContentChecksum(additions []gitrepo.Addition) string {
+	byPath := map[string]gitrepo.Addition{}
+	for _, addition := range additions {
+		if _, ok := byPath[string(addition.Path)]; !ok {
+			byPath[string(addition.Path)] = addition
+		}
+	}
+	return utility.CollectiveSHA256HashOf(sortedPaths(additions), func(filePath string) ([]byte, error) {
+		return byPath[filePath].Content()
+	})
+}
+
+//sortedPaths returns the distinct paths of the additions, sorted
+func sortedPaths(additions []gitrepo.Addition) []string {
+	var paths []string
+	for _, addition := range additions {
+		paths = append(paths, string(addition.Path))
+	}
+	paths = utility.UniqueItems(paths)
+	sort.Strings(paths)
+	return paths
+}
+
 func (cc *ChecksumCompare) calculateCollectiveChecksumForPattern(fileNamePattern string, additions []gitrepo.Addition) string {
-	var patternpaths []string
-	currentCollectiveChecksum := ""
+	var matching []gitrepo.Addition
 	for _, addition := range additions {
 		if addition.Matches(fileNamePattern) {
-			patternpaths = append(patternpaths, string(addition.Path))
+			matching = append(matching, addition)
 		}
 	}
-	// Calculate current collective checksum
-	patternpaths = utility.UniqueItems(patternpaths)
-	if len(patternpaths) != 0 {
-		currentCollectiveChecksum = utility.CollectiveSHA256Hash(patternpaths)
+	if len(matching) == 0 {
+		return ""
 	}
-	return currentCollectiveChecksum
+	return ContentChecksum(matching)
 }
@@ -1,6 +1,9 @@
 package detector
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"talisman/gitrepo"
 	"talisman/utility"
 	"testing"
@@ -90,3 +93,85 @@ func TestShouldReturnEmptyFileHashWhenNoPathsPassed(t *testing.T) {
 	checksum := utility.CollectiveSHA256Hash([]string{})
 	assert.Equal(t, checksum, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "Should be equal to empty hash value when no paths passed")
 }
+
+func TestShouldChecksumTheContentBeingScannedRatherThanTheWorkingTree(t *testing.T) {
+	reviewed := gitrepo.NewAddition("some_file.pem", []byte("reviewed content"))
+	rc := TalismanRCIgnore{FileIgnoreConfig: []FileIgnoreConfig{{FileName: "some_file.pem", Checksum: ContentChecksum([]gitrepo.Addition{reviewed})}}}
+	changed := gitrepo.NewAddition("some_file.pem", []byte("changed content"))
+
+	assert.True(t, NewChecksumCompare([]gitrepo.Addition{reviewed}, rc).IsScanNotRequired(reviewed, "filecontent"))
+	assert.False(t, NewChecksumCompare([]gitrepo.Addition{changed}, rc).IsScanNotRequired(changed, "filecontent"), "Expected the changed content to be scanned, although the working tree has no such file")
+}
+
+func TestShouldChecksumEveryAdditionThatMatchesAPattern(t *testing.T) {
+	first := gitrepo.NewAddition("keys/first.pem", []byte("first"))
+	second := gitrepo.NewAddition("keys/second.pem", []byte("second"))
+	other := gitrepo.NewAddition("notes.txt", []byte("notes"))
+	rc := TalismanRCIgnore{FileIgnoreConfig: []FileIgnoreConfig{{FileName: "keys/*.pem", Checksum: ContentChecksum([]gitrepo.Addition{second, first})}}}
+	cc := NewChecksumCompare([]gitrepo.Addition{first, second, other}, rc)
+
+	assert.True(t, cc.IsScanNotRequired(first, "filecontent"))
+	assert.True(t, cc.IsScanNotRequired(second, "filecontent"))
+	assert.False(t, cc.IsScanNotRequired(other, "filecontent"))
+	assert.Len(t, cc.FilterIgnoresBasedOnChecksums().FileIgnoreConfig, 1)
+}
+
+func TestShouldChecksumEveryIndexedFileThatMatchesAPatternWhenOnlySomeOfThemChange(t *testing.T) {
+	first := gitrepo.NewAddition("keys/first.pem", []byte("first"))
+	second := gitrepo.NewAddition("keys/second.pem", []byte("second"))
+	changedSecond := gitrepo.NewAddition("keys/second.pem", []byte("changed second"))
+	indexed := []gitrepo.Addition{first, changedSecond}
+	rc := TalismanRCIgnore{FileIgnoreConfig: []FileIgnoreConfig{{FileName: "keys/*.pem", Checksum: ContentChecksum([]gitrepo.Addition{first, second})}}}
+
+	assert.True(t, NewChecksumCompare([]gitrepo.Addition{second}, rc.WithIndexedFiles(indexed)).IsScanNotRequired(second, "filecontent"), "Expected the unchanged file of the pattern to be checksummed from the index, and the changed one from the scanned content")
+	assert.False(t, NewChecksumCompare([]gitrepo.Addition{second}, rc).IsScanNotRequired(second, "filecontent"), "Expected the checksum not to match without the unchanged files of the pattern")
+	assert.False(t, NewChecksumCompare([]gitrepo.Addition{changedSecond}, rc.WithIndexedFiles(indexed)).IsScanNotRequired(changedSecond, "filecontent"), "Expected the changed file to be scanned")
+}
+
+func TestShouldChecksumLikeTheWorkingTreeForUnchangedContent(t *testing.T) {
+	assert.Equal(t, utility.CollectiveSHA256Hash([]string{"some_file.pem"}), ContentChecksum([]gitrepo.Addition{gitrepo.NewAddition("some_file.pem", []byte{})}), "Expected existing checksums to stay valid as long as the content is unchanged")
+}
+
+func TestShouldWarnAboutChecksumsCalculatedFromADifferentWorkingTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "talisman-checksum")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.txt"), []byte("working tree content\r\n"), 0644))
+	nested := TalismanRCIgnore{
+		FileIgnoreConfig: []FileIgnoreConfig{{FileName: "config.txt", Checksum: utility.CollectiveSHA256HashIn(dir, []string{"config.txt"})}},
+		Source:           "team/.talismanrc",
+		Directory:        dir,
+	}
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filepath.Join(dir, "config.txt"), []byte("working tree content\n"))}
+	results := NewDetectionResults()
+
+	NewChecksumCompare(additions, TalismanRCIgnore{Nested: []TalismanRCIgnore{nested}}).ReportLegacyChecksums(results)
+
+	assert.True(t, results.HasWarnings(), "Expected a checksum calculated from the working tree to be warned about")
+	assert.False(t, results.HasFailures(), "Expected a checksum calculated from the working tree not to fail the run")
+	assert.Len(t, results.ReportFileWarnings("team/.talismanrc"), 1)
+}
+
+func TestShouldFindIgnoresThatMatchNoFileOrHaveAStaleChecksum(t *testing.T) {
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("team/private.pem", []byte("changed key")),
+		gitrepo.NewAddition("team/yarn.lock", []byte("lock")),
+	}
+	nested := TalismanRCIgnore{
+		FileIgnoreConfig: []FileIgnoreConfig{
+			{FileName: "private.pem", Checksum: ContentChecksum([]gitrepo.Addition{gitrepo.NewAddition("private.pem", []byte("key"))})},
+			{FileName: "*.lock"},
+			{FileName: "deleted.key", Checksum: "abc"},
+		},
+		Directory: "team",
+	}
+
+	stale := NewChecksumCompare(additions, TalismanRCIgnore{}).StaleIgnores(nested)
+
+	assert.Equal(t, []StaleIgnore{
+		{Index: 0, Ignore: nested.FileIgnoreConfig[0], Checksum: ContentChecksum([]gitrepo.Addition{gitrepo.NewAddition("private.pem", []byte("changed key"))})},
+		{Index: 2, Ignore: nested.FileIgnoreConfig[2]},
+	}, stale)
+	assert.Equal(t, "the checksum of private.pem no longer matches its content", stale[0].String())
+	assert.Equal(t, "deleted.key no longer matches any file", stale[1].String())
+}
@@ -0,0 +1,178 @@
+package detector
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"talisman/utility"
+)
+
+const (
+	//PolicyFileEnvVar names the environment variable that points to the organisation policy file
+	PolicyFileEnvVar string = "TALISMAN_POLICY"
+
+	//DefaultPolicyFilePath is where the organisation policy file is looked for, unless PolicyFileEnvVar points elsewhere
+	DefaultPolicyFilePath string = "/etc/talisman/policy.talismanrc"
+)
+
+//LockableSettings lists the settings that an organisation policy can lock, so that the user-global and repository .talismanrc files cannot change them
+var LockableSettings = []string{"fileignoreconfig", "scopeconfig", "placeholderconfig", "dictionaryconfig", "expiryconfig", "detector_error_policy"}
+
+//PolicyFilePath returns the path of the organisation policy file
+func PolicyFilePath() string {
+	if policyFilePath := os.Getenv(PolicyFileEnvVar); policyFilePath != "" {
+		return policyFilePath
+	}
+	return DefaultPolicyFilePath
+}
+
+//UserRCFilePath returns the path of the user-global .talismanrc, or an empty string if the home directory is unknown
+func UserRCFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, DefaultRCFileName)
+}
+
+//ReadConfigLayer reads a .talismanrc that lives outside of the repository, such as the organisation policy or the user-global file.
+//A missing file is an empty config. Dictionary files listed in it are relative to its directory.
+func ReadConfigLayer(filePath string) TalismanRCIgnore {
+	if filePath == "" {
+		return TalismanRCIgnore{}
+	}
+	fileContents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("error reading %s: %v", filePath, err)
+		}
+		return TalismanRCIgnore{}
+	}
+	config := NewTalismanRCIgnore(fileContents)
+	for i, file := range config.DictionaryConfig.Files {
+		if !filepath.IsAbs(file) {
+			config.DictionaryConfig.Files[i] = filepath.Join(filepath.Dir(filePath), file)
+		}
+	}
+	return config
+}
+
+//ReadLayeredConfig merges the organisation policy and the user-global .talismanrc into the config read from the repository
+func ReadLayeredConfig(repo TalismanRCIgnore) TalismanRCIgnore {
+	return MergeConfigLayers(ReadConfigLayer(PolicyFilePath()), ReadConfigLayer(UserRCFilePath()), repo)
+}
+
+//MergeConfigLayers merges the organisation policy, the user-global and the repository configs, in that order.
+//
+//File ignores, scopes, placeholder dummy values and dictionary words of every layer are combined.
+//The placeholder action, the expiry settings and the detector error policy of a later layer override those of an earlier one.
+//Mandatory detectors, forbidden ignores and custom patterns are combined as well, so that a later layer can only add to them.
+//Likewise, a reason is required for ignores if any layer requires it.
+//A setting that the policy locks is taken from the policy alone, including the nested .talismanrc files of the repository.
+func MergeConfigLayers(policy TalismanRCIgnore, user TalismanRCIgnore, repo TalismanRCIgnore) TalismanRCIgnore {
+	merged := repo
+	layers := []TalismanRCIgnore{policy, user, repo}
+	overrides := append([]TalismanRCIgnore{user, repo}, repo.Nested...)
+	isLocked := func(setting string, isSet func(layer TalismanRCIgnore) bool) bool {
+		if !contains(policy.Locked, setting) {
+			return false
+		}
+		for _, layer := range overrides {
+			if isSet(layer) {
+				log.Printf("%s is locked by the organisation policy, so it is only read from %s", setting, PolicyFilePath())
+				break
+			}
+		}
+		return true
+	}
+
+	merged.FileIgnoreConfig = nil
+	merged.ScopeConfig = nil
+	merged.PlaceholderConfig = PlaceholderConfig{}
+	merged.DictionaryConfig = DictionaryConfig{}
+	merged.ExpiryConfig = ExpiryConfig{}
+	merged.DetectorErrorPolicy = ""
+	merged.RequireIgnoreReason = false
+	merged.MandatoryDetectors = nil
+	merged.ForbiddenIgnores = nil
+	merged.CustomPatterns = nil
+	merged.Locked = policy.Locked
+
+	fileIgnoresLocked := isLocked("fileignoreconfig", func(layer TalismanRCIgnore) bool { return len(layer.FileIgnoreConfig) > 0 })
+	scopesLocked := isLocked("scopeconfig", func(layer TalismanRCIgnore) bool { return len(layer.ScopeConfig) > 0 })
+	placeholdersLocked := isLocked("placeholderconfig", func(layer TalismanRCIgnore) bool {
+		return !reflect.DeepEqual(layer.PlaceholderConfig, PlaceholderConfig{})
+	})
+	dictionaryLocked := isLocked("dictionaryconfig", func(layer TalismanRCIgnore) bool {
+		return !reflect.DeepEqual(layer.DictionaryConfig, DictionaryConfig{})
+	})
+	expiryLocked := isLocked("expiryconfig", func(layer TalismanRCIgnore) bool { return layer.ExpiryConfig != ExpiryConfig{} })
+	errorPolicyLocked := isLocked("detector_error_policy", func(layer TalismanRCIgnore) bool { return layer.DetectorErrorPolicy != "" })
+
+	for i, layer := range layers {
+		isPolicy := i == 0
+		if isPolicy || !fileIgnoresLocked {
+			merged.FileIgnoreConfig = append(merged.FileIgnoreConfig, layer.FileIgnoreConfig...)
+		}
+		if isPolicy || !scopesLocked {
+			merged.ScopeConfig = append(merged.ScopeConfig, layer.ScopeConfig...)
+		}
+		if isPolicy || !placeholdersLocked {
+			merged.PlaceholderConfig.DummyValues = append(merged.PlaceholderConfig.DummyValues, layer.PlaceholderConfig.DummyValues...)
+			if layer.PlaceholderConfig.Action != "" {
+				merged.PlaceholderConfig.Action = layer.PlaceholderConfig.Action
+			}
+		}
+		if isPolicy || !dictionaryLocked {
+			merged.DictionaryConfig.Words = append(merged.DictionaryConfig.Words, layer.DictionaryConfig.Words...)
+			merged.DictionaryConfig.Files = append(merged.DictionaryConfig.Files, layer.DictionaryConfig.Files...)
+		}
+		if isPolicy || !expiryLocked {
+			merged.ExpiryConfig = merged.ExpiryConfig.overriddenBy(layer.ExpiryConfig)
+		}
+		if (isPolicy || !errorPolicyLocked) && layer.DetectorErrorPolicy != "" {
+			merged.DetectorErrorPolicy = layer.DetectorErrorPolicy
+		}
+		merged.RequireIgnoreReason = merged.RequireIgnoreReason || layer.RequireIgnoreReason
+		merged.MandatoryDetectors = union(merged.MandatoryDetectors, layer.MandatoryDetectors)
+		merged.ForbiddenIgnores = union(merged.ForbiddenIgnores, layer.ForbiddenIgnores)
+		merged.CustomPatterns = union(merged.CustomPatterns, layer.CustomPatterns)
+	}
+
+	merged.Nested = nil
+	for _, nested := range repo.Nested {
+		if fileIgnoresLocked {
+			nested.FileIgnoreConfig = nil
+		}
+		if scopesLocked {
+			nested.ScopeConfig = nil
+		}
+		merged.Nested = append(merged.Nested, nested)
+	}
+	return merged
+}
+
+//overriddenBy returns the expiry settings, with those that the later layer sets taking precedence
+func (config ExpiryConfig) overriddenBy(later ExpiryConfig) ExpiryConfig {
+	if later.Action != "" {
+		config.Action = later.Action
+	}
+	if later.NoticeDays != 0 {
+		config.NoticeDays = later.NoticeDays
+	}
+	if later.DefaultDays != 0 {
+		config.DefaultDays = later.DefaultDays
+	}
+	return config
+}
+
+//union returns the items of both lists, without duplicates
+func union(items []string, moreItems []string) []string {
+	if len(moreItems) == 0 {
+		return items
+	}
+	return utility.UniqueItems(append(items, moreItems...))
+}
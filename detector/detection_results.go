@@ -10,6 +10,7 @@ import (
 	"talisman/gitrepo"
 	"talisman/prompt"
 	"talisman/utility"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -18,6 +19,18 @@ type Details struct {
 	Category string   `json:"type"`
 	Message  string   `json:"message"`
 	Commits  []string `json:"commits"`
+	//Justification is recorded for ignored files only
+	Justification *Justification `json:"justification,omitempty"`
+	//Value is the detected value of a failure, if the detector knows it
+	Value string `json:"-"`
+}
+
+//Justification records why a file is ignored, as configured in its fileignoreconfig entry
+type Justification struct {
+	Reason  string `json:"reason"`
+	Owner   string `json:"owner"`
+	Ticket  string `json:"ticket"`
+	Expires string `json:"expires,omitempty"`
 }
 
 type ResultsDetails struct {
@@ -25,6 +38,8 @@ type ResultsDetails struct {
 	FailureList []Details        `json:"failure_list"`
 	WarningList []Details        `json:"warning_list"`
 	IgnoreList  []Details        `json:"ignore_list"`
+	//Refs are the pushed refs that the file was found in, if it was found in any
+	Refs []string `json:"refs,omitempty"`
 }
 
 type FailureTypes struct {
@@ -33,6 +48,8 @@ type FailureTypes struct {
 	Filename    int `json:"filename"`
 	Warnings    int `json:"warnings"`
 	Ignores     int `json:"ignores"`
+	Errors      int `json:"errors"`
+	Expired     int `json:"expired"`
 }
 
 type ResultsSummary struct {
@@ -52,6 +69,13 @@ type ResultsSummary struct {
 type DetectionResults struct {
 	Summary ResultsSummary   `json:"summary"`
 	Results []ResultsDetails `json:"results"`
+	//defaultExpiry is the expiry date of the entries suggested for .talismanrc, if any
+	defaultExpiry string
+	//reasonRequired tells whether the entries suggested for .talismanrc need a reason
+	reasonRequired bool
+	//scanned holds the additions that the entries suggested for .talismanrc are checksummed from, by their path.
+	//A path has several additions when it changes in several of the scanned commits.
+	scanned map[gitrepo.FilePath][]gitrepo.Addition
 }
 
 func (r *ResultsDetails) getWarningDataByCategoryAndMessage(failureMessage string, category string) *Details {
@@ -63,7 +87,7 @@ func (r *ResultsDetails) getWarningDataByCategoryAndMessage(failureMessage strin
 func (r *ResultsDetails) getFailureDataByCategoryAndMessage(failureMessage string, category string) *Details {
 	detail := getDetaisByCategoryAndMessage(r.FailureList, category, failureMessage)
 	if detail == nil {
-		detail = &Details{category, failureMessage, make([]string, 0)}
+		detail = &Details{Category: category, Message: failureMessage, Commits: make([]string, 0)}
 		r.FailureList = append(r.FailureList, *detail)
 	}
 	return detail
@@ -77,7 +101,7 @@ func (r *ResultsDetails) addIgnoreDataByCategory(category string) {
 		}
 	}
 	if !isCategoryAlreadyPresent {
-		detail := Details{category, "", make([]string, 0)}
+		detail := Details{Category: category, Message: "", Commits: make([]string, 0)}
 		r.IgnoreList = append(r.IgnoreList, detail)
 	}
 }
@@ -105,7 +129,7 @@ func (r *DetectionResults) getResultDetailsForFilePath(fileName gitrepo.FilePath
 
 //NewDetectionResults is a new DetectionResults struct. It represents the pre-run state of a Detection run.
 func NewDetectionResults() *DetectionResults {
-	result := DetectionResults{ResultsSummary{FailureTypes{0, 0, 0, 0, 0}}, make([]ResultsDetails, 0)}
+	result := DetectionResults{Summary: ResultsSummary{FailureTypes{0, 0, 0, 0, 0, 0, 0}}, Results: make([]ResultsDetails, 0)}
 	return &result
 }
 
@@ -113,6 +137,11 @@ func NewDetectionResults() *DetectionResults {
 //Detectors are encouraged to provide context sensitive messages so that fixing the errors is made simple for the end user
 //Fail may be called multiple times for each FilePath and the calls accumulate the provided reasons
 func (r *DetectionResults) Fail(filePath gitrepo.FilePath, category string, message string, commits []string) {
+	r.FailWithValue(filePath, category, message, "", commits)
+}
+
+//FailWithValue is Fail for detectors that know the value that was detected, which lets the user allowlist it when triaging the failure
+func (r *DetectionResults) FailWithValue(filePath gitrepo.FilePath, category string, message string, value string, commits []string) {
 	isFilePresentInResults := false
 	for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
 		if r.Results[resultIndex].Filename == filePath {
@@ -125,13 +154,13 @@ func (r *DetectionResults) Fail(filePath gitrepo.FilePath, category string, mess
 				}
 			}
 			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, Details{category, message, commits})
+				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, Details{Category: category, Message: message, Commits: commits, Value: value})
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		failureDetails := Details{category, message, commits}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		failureDetails := Details{Category: category, Message: message, Commits: commits, Value: value}
+		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0), nil}
 		resultDetails.FailureList = append(resultDetails.FailureList, failureDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
@@ -151,13 +180,13 @@ func (r *DetectionResults) Warn(filePath gitrepo.FilePath, category string, mess
 				}
 			}
 			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, Details{category, message, commits})
+				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, Details{Category: category, Message: message, Commits: commits})
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		warningDetails := Details{category, message, commits}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		warningDetails := Details{Category: category, Message: message, Commits: commits}
+		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0), nil}
 		resultDetails.WarningList = append(resultDetails.WarningList, warningDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
@@ -167,7 +196,16 @@ func (r *DetectionResults) Warn(filePath gitrepo.FilePath, category string, mess
 //Ignore is used to mark the supplied FilePath as being ignored.
 //The most common reason for this is that the FilePath is Denied by the Ignores supplied to the Detector, however, Detectors may use more sophisticated reasons to ignore files.
 func (r *DetectionResults) Ignore(filePath gitrepo.FilePath, category string) {
+	r.ignore(filePath, category, "", Justification{})
+}
 
+//IgnoreFrom is used to mark the supplied FilePath as being ignored as configured in the supplied .talismanrc file, which is recorded in the message of the ignore.
+//The justification of the fileignoreconfig entry that ignores the file is recorded along with it.
+func (r *DetectionResults) IgnoreFrom(filePath gitrepo.FilePath, category string, source string, justification Justification) {
+	r.ignore(filePath, category, fmt.Sprintf("Ignored as configured in %s", source), justification)
+}
+
+func (r *DetectionResults) ignore(filePath gitrepo.FilePath, category string, message string, justification Justification) {
 	isFilePresentInResults := false
 	for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
 		if r.Results[resultIndex].Filename == filePath {
@@ -180,14 +218,14 @@ func (r *DetectionResults) Ignore(filePath gitrepo.FilePath, category string) {
 				}
 			}
 			if !isEntryPresentForGivenCategory {
-				detail := Details{category, "", make([]string, 0)}
+				detail := Details{Category: category, Message: message, Commits: make([]string, 0), Justification: &justification}
 				r.Results[resultIndex].IgnoreList = append(r.Results[resultIndex].IgnoreList, detail)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		ignoreDetails := Details{category, "", make([]string, 0)}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		ignoreDetails := Details{Category: category, Message: message, Commits: make([]string, 0), Justification: &justification}
+		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0), nil}
 		resultDetails.IgnoreList = append(resultDetails.IgnoreList, ignoreDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
@@ -195,8 +233,8 @@ func (r *DetectionResults) Ignore(filePath gitrepo.FilePath, category string) {
 }
 
 func createNewResultForFile(category string, message string, commits []string, filePath gitrepo.FilePath) ResultsDetails {
-	failureDetails := Details{category, message, commits}
-	resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+	failureDetails := Details{Category: category, Message: message, Commits: commits}
+	resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0), nil}
 	resultDetails.FailureList = append(resultDetails.FailureList, failureDetails)
 	return resultDetails
 }
@@ -208,13 +246,17 @@ func (r *DetectionResults) updateResultsSummary(category string) {
 		r.Summary.Types.Filename++
 	} else if strings.Compare("filesize", category) == 0 {
 		r.Summary.Types.Filesize++
+	} else if strings.Compare(ErrorCategory, category) == 0 {
+		r.Summary.Types.Errors++
+	} else if strings.Compare(ExpiryCategory, category) == 0 {
+		r.Summary.Types.Expired++
 	}
 
 }
 
 //HasFailures answers if any Failures were detected for any FilePath in the current run
 func (r *DetectionResults) HasFailures() bool {
-	return r.Summary.Types.Filesize > 0 || r.Summary.Types.Filename > 0 || r.Summary.Types.Filecontent > 0
+	return r.Summary.Types.Filesize > 0 || r.Summary.Types.Filename > 0 || r.Summary.Types.Filecontent > 0 || r.Summary.Types.Errors > 0 || r.Summary.Types.Expired > 0
 }
 
 //HasIgnores answers if any FilePaths were ignored in the current run
@@ -284,7 +326,9 @@ func (r *DetectionResults) Report(fs afero.Fs, ignoreFile string, promptContext
 
 	for _, resultDetails := range r.Results {
 		if len(resultDetails.FailureList) > 0 || len(resultDetails.IgnoreList) > 0 {
-			filePathsForIgnoresAndFailures = append(filePathsForIgnoresAndFailures, string(resultDetails.Filename))
+			if resultDetails.mayBeIgnored() {
+				filePathsForIgnoresAndFailures = append(filePathsForIgnoresAndFailures, string(resultDetails.Filename))
+			}
 			failureData := r.ReportFileFailures(resultDetails.Filename)
 			data = append(data, failureData...)
 		}
@@ -296,102 +340,221 @@ func (r *DetectionResults) Report(fs afero.Fs, ignoreFile string, promptContext
 		fmt.Printf("\n\x1b[1m\x1b[31mTalisman Report:\x1b[0m\x1b[0m\n")
 		table.AppendBulk(data)
 		table.Render()
-		r.suggestTalismanRC(fs, ignoreFile, filePathsForIgnoresAndFailures, promptContext)
+		if len(filePathsForIgnoresAndFailures) > 0 {
+			r.suggestTalismanRC(fs, ignoreFile, filePathsForIgnoresAndFailures, promptContext)
+		}
 	}
 	return result
 }
 
-func (r *DetectionResults) suggestTalismanRC(fs afero.Fs, ignoreFile string, filePaths []string, promptContext prompt.PromptContext) {
-	var entriesToAdd []FileIgnoreConfig
+//mayBeIgnored answers if an entry of .talismanrc can make a difference to the results of the file.
+//Expired fileignoreconfig entries are reported against the .talismanrc they are in, which ignoring would not fix.
+func (r ResultsDetails) mayBeIgnored() bool {
+	if len(r.IgnoreList) > 0 {
+		return true
+	}
+	for _, failure := range r.FailureList {
+		if failure.Category != ExpiryCategory {
+			return true
+		}
+	}
+	return false
+}
 
-	for _, filePath := range filePaths {
-		currentChecksum := utility.CollectiveSHA256Hash([]string{filePath})
-		fileIgnoreConfig := FileIgnoreConfig{filePath, currentChecksum, []string{}}
-		entriesToAdd = append(entriesToAdd, fileIgnoreConfig)
+//AttributeRefs records the pushed refs that every file with results was found in, as recorded on the supplied additions
+func (r *DetectionResults) AttributeRefs(additions []gitrepo.Addition) {
+	for _, addition := range additions {
+		for resultIndex := range r.Results {
+			if r.Results[resultIndex].Filename == addition.Path {
+				r.Results[resultIndex].Refs = utility.UniqueItems(append(r.Results[resultIndex].Refs, addition.Refs...))
+			}
+		}
 	}
+}
 
-	if promptContext.Interactive {
-		confirmedEntries := getUserConfirmation(entriesToAdd, promptContext)
-		addToTalismanIgnoreFile(confirmedEntries, fs, ignoreFile)
-	} else {
-		printTalismanIgnoreSuggestion(entriesToAdd)
+//ChecksumContentOf makes the entries suggested for .talismanrc checksummed from the content of the supplied additions,
+//which is the content that was scanned, rather than from the working tree
+func (r *DetectionResults) ChecksumContentOf(additions []gitrepo.Addition) {
+	r.scanned = map[gitrepo.FilePath][]gitrepo.Addition{}
+	for _, addition := range additions {
+		r.scanned[addition.Path] = append(r.scanned[addition.Path], addition)
+	}
+}
+
+//scannedAdditionOf returns the scanned addition that the failure was found in, which is the one of a commit of the failure.
+//A failure without commits is found in the only addition of its file.
+func (r *DetectionResults) scannedAdditionOf(filePath string, failure Details) (gitrepo.Addition, bool) {
+	for _, addition := range r.scanned[gitrepo.FilePath(filePath)] {
+		if len(failure.Commits) == 0 || sharesCommit(addition.Commits, failure.Commits) {
+			return addition, true
+		}
+	}
+	return gitrepo.Addition{}, false
+}
+
+func sharesCommit(commits []string, others []string) bool {
+	for _, commit := range commits {
+		if contains(others, commit) {
+			return true
+		}
+	}
+	return false
+}
+
+//RequireReason makes the entries suggested for .talismanrc require a reason.
+//When interactive, entries that the user gives no reason for are not added.
+func (r *DetectionResults) RequireReason(required bool) {
+	r.reasonRequired = required
+}
+
+//SuggestExpiry makes the entries suggested for .talismanrc expire on the supplied date, formatted as ExpiryDateFormat.
+//When interactive, the user is asked for the expiry date of every entry they add, with the supplied date as the default.
+//An empty date suggests entries that never expire.
+func (r *DetectionResults) SuggestExpiry(expires string) {
+	r.defaultExpiry = expires
+}
+
+func (r *DetectionResults) suggestTalismanRC(fs afero.Fs, ignoreFile string, filePaths []string, promptContext prompt.PromptContext) {
+	if !promptContext.Interactive {
+		var entriesToAdd []FileIgnoreConfig
+		for _, filePath := range filePaths {
+			var failure Details
+			if failures := r.GetFailures(gitrepo.FilePath(filePath)); len(failures) > 0 {
+				failure = failures[0]
+			}
+			entriesToAdd = append(entriesToAdd, r.suggestedEntryFor(filePath, failure))
+		}
+		printTalismanIgnoreSuggestion(entriesToAdd, nil, r.reasonRequired)
 		return
 	}
 
+	triaged, ok := r.triageFailures(filePaths, promptContext)
+	if !ok {
+		fmt.Printf("\nAborted, nothing was added to %s\n", ignoreFile)
+		return
+	}
+	confirmedEntries := triaged.entries
+	if r.defaultExpiry != "" {
+		confirmedEntries = askForExpiry(confirmedEntries, promptContext)
+	}
+	confirmedEntries = askForJustification(confirmedEntries, promptContext, r.reasonRequired)
+	err := addToTalismanIgnoreFile(confirmedEntries, triaged.dummyValues, fs, ignoreFile)
+	if err != nil {
+		log.Printf("error adding to %s: %s", ignoreFile, err)
+		printTalismanIgnoreSuggestion(confirmedEntries, triaged.dummyValues, r.reasonRequired)
+	}
 }
 
-func getUserConfirmation(configs []FileIgnoreConfig, promptContext prompt.PromptContext) []FileIgnoreConfig {
-	confirmed := []FileIgnoreConfig{}
+//suggestedEntryFor returns the entry that ignores the file with the checksum of the scanned content that the failure was found in
+func (r *DetectionResults) suggestedEntryFor(filePath string, failure Details) FileIgnoreConfig {
+	currentChecksum := utility.CollectiveSHA256Hash([]string{filePath})
+	if addition, ok := r.scannedAdditionOf(filePath, failure); ok {
+		currentChecksum = ContentChecksum([]gitrepo.Addition{addition})
+	}
+	return FileIgnoreConfig{FileName: filePath, Checksum: currentChecksum, IgnoreDetectors: []string{}, Expires: r.defaultExpiry}
+}
+
+//askForExpiry lets the user change the expiry date of every confirmed entry, keeping the suggested one for answers that are not a date
+func askForExpiry(configs []FileIgnoreConfig, promptContext prompt.PromptContext) []FileIgnoreConfig {
+	for i, config := range configs {
+		message := fmt.Sprintf("When should the entry for %s expire? (%s)", config.FileName, ExpiryDateFormat)
+		expires := strings.TrimSpace(promptContext.Prompt.Input(message, config.Expires))
+		if _, err := time.Parse(ExpiryDateFormat, expires); err != nil {
+			log.Printf("%q is not a date such as %s, the entry for %s expires on %s", expires, ExpiryDateFormat, config.FileName, config.Expires)
+			continue
+		}
+		configs[i].Expires = expires
+	}
+	return configs
+}
+
+//askForJustification asks the user for the reason, owner and ticket of every confirmed entry.
+//Entries without a reason are dropped if a reason is required.
+func askForJustification(configs []FileIgnoreConfig, promptContext prompt.PromptContext, reasonRequired bool) []FileIgnoreConfig {
+	justified := []FileIgnoreConfig{}
 	for _, config := range configs {
-		if confirm(config, promptContext) {
-			confirmed = append(confirmed, config)
+		config.Reason = strings.TrimSpace(promptContext.Prompt.Input(fmt.Sprintf("Why should %s be ignored?", config.FileName), config.Reason))
+		if config.Reason == "" && reasonRequired {
+			log.Printf("not adding the entry for %s to talismanrc, as a reason is required", config.FileName)
+			continue
 		}
+		config.Owner = strings.TrimSpace(promptContext.Prompt.Input("Who owns this entry?", config.Owner))
+		config.Ticket = strings.TrimSpace(promptContext.Prompt.Input("Which ticket tracks this entry?", config.Ticket))
+		justified = append(justified, config)
 	}
-	return confirmed
+	return justified
 }
 
-func printTalismanIgnoreSuggestion(entriesToAdd []FileIgnoreConfig) {
-	talismanRcIgnoreConfig := TalismanRCIgnore{FileIgnoreConfig: entriesToAdd}
+func printTalismanIgnoreSuggestion(entriesToAdd []FileIgnoreConfig, dummyValues []string, reasonRequired bool) {
+	talismanRcIgnoreConfig := TalismanRCIgnore{FileIgnoreConfig: entriesToAdd, PlaceholderConfig: PlaceholderConfig{DummyValues: dummyValues}}
 	ignoreEntries, _ := yaml.Marshal(&talismanRcIgnoreConfig)
 	suggestString := fmt.Sprintf("\n\x1b[33mIf you are absolutely sure that you want to ignore the " +
 		"above files from talisman detectors, consider pasting the following format in .talismanrc file" +
 		" in the project root\x1b[0m\n")
 	fmt.Println(suggestString)
+	if reasonRequired {
+		fmt.Printf("\x1b[33mA reason is required for every entry, along with an owner and a ticket if possible\x1b[0m\n\n")
+	}
 	fmt.Println(string(ignoreEntries))
 }
 
-func addToTalismanIgnoreFile(entriesToAdd []FileIgnoreConfig, fs afero.Fs, ignoreFile string) {
-
+//addToTalismanIgnoreFile adds the entries and the dummy values to the ignore file, keeping everything that is already in it as it is
+func addToTalismanIgnoreFile(entriesToAdd []FileIgnoreConfig, dummyValues []string, fs afero.Fs, ignoreFile string) error {
+	if len(entriesToAdd) == 0 && len(dummyValues) == 0 {
+		return nil
+	}
+	contents, err := afero.ReadFile(fs, ignoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	if len(entriesToAdd) > 0 {
-		talismanRcIgnoreConfig := TalismanRCIgnore{FileIgnoreConfig: entriesToAdd}
-		ignoreEntries, _ := yaml.Marshal(&talismanRcIgnoreConfig)
-		file, err := fs.OpenFile(ignoreFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Printf("error opening %s: %s", ignoreFile, err)
-		}
-		defer func() {
-			err := file.Close()
-			if err != nil {
-				log.Printf("error closing %s: %s", ignoreFile, err)
-			}
-
-		}()
-
-		_, err = file.WriteString(string(ignoreEntries))
+		contents, err = AppendFileIgnores(contents, entriesToAdd)
 		if err != nil {
-			log.Printf("error writing to %s: %s", ignoreFile, err)
+			return err
 		}
 	}
-}
-
-func confirm(config FileIgnoreConfig, promptContext prompt.PromptContext) bool {
-	bytes, err := yaml.Marshal(&config)
+	contents, err = AppendDummyValues(contents, dummyValues)
 	if err != nil {
-		log.Printf("error marshalling file ignore config: %s", err)
+		return err
 	}
-
-	fmt.Println(string(bytes))
-
-	confirmationString := "Do you want to add this entry in talismanrc ?"
-
-	return promptContext.Prompt.Confirm(confirmationString)
+	return afero.WriteFile(fs, ignoreFile, contents, 0644)
 }
 
 //ReportFileFailures adds a string to table documenting the various failures detected on the supplied FilePath by all detectors in the current run
 func (r *DetectionResults) ReportFileFailures(filePath gitrepo.FilePath) [][]string {
-	failureList := r.getResultDetailsForFilePath(filePath).FailureList
+	resultDetails := r.getResultDetailsForFilePath(filePath)
+	failureList := resultDetails.FailureList
+	fileName := string(filePath)
+	if len(resultDetails.Refs) > 0 {
+		fileName = fmt.Sprintf("%s\n(%s)", filePath, strings.Join(resultDetails.Refs, ", "))
+	}
 	var data [][]string
 	if len(failureList) > 0 {
 		for _, detail := range failureList {
 			if len(detail.Message) > 150 {
 				detail.Message = detail.Message[:150] + "\n" + detail.Message[150:]
 			}
-			data = append(data, []string{string(filePath), detail.Message})
+			if len(detail.Commits) > 0 {
+				detail.Message = fmt.Sprintf("%s\n(in %s)", detail.Message, strings.Join(shortCommits(detail.Commits), ", "))
+			}
+			data = append(data, []string{fileName, detail.Message})
 		}
 	}
 	return data
 }
 
+//shortCommits returns the distinct commits, abbreviated like git abbreviates them
+func shortCommits(commits []string) []string {
+	var result []string
+	for _, commit := range utility.UniqueItems(commits) {
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		result = append(result, commit)
+	}
+	return result
+}
+
 func (r *DetectionResults) ReportFileWarnings(filePath gitrepo.FilePath) [][]string {
 	warningList := r.getResultDetailsForFilePath(filePath).WarningList
 	var data [][]string
@@ -1,9 +1,11 @@
 package detector
 
 import (
+	"encoding/json"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/afero"
 	"strings"
+	"talisman/gitrepo"
 	mock "talisman/internal/mock/prompt"
 	"talisman/prompt"
 	"testing"
@@ -91,7 +93,7 @@ scopeconfig: []
 	// which they run matters.
 	t.Run("should not prompt if there are no failures", func(t *testing.T) {
 		promptContext := prompt.NewPromptContext(true, prompter)
-		prompter.EXPECT().Confirm(gomock.Any()).Return(false).Times(0)
+		prompter.EXPECT().Select(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 		results.Report(fs, ignoreFile, promptContext)
 		bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
@@ -102,7 +104,7 @@ scopeconfig: []
 
 	t.Run("when user declines, entry should not be added to talismanrc", func(t *testing.T) {
 		promptContext := prompt.NewPromptContext(true, prompter)
-		prompter.EXPECT().Confirm("Do you want to add this entry in talismanrc ?").Return(false)
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(keepFailingChoice)
 		results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
 
 		results.Report(fs, ignoreFile, promptContext)
@@ -114,7 +116,7 @@ scopeconfig: []
 
 	t.Run("when interactive flag is set to false, it should not ask user", func(t *testing.T) {
 		promptContext := prompt.NewPromptContext(false, prompter)
-		prompter.EXPECT().Confirm(gomock.Any()).Return(false).Times(0)
+		prompter.EXPECT().Select(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 		results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
 
 		results.Report(fs, ignoreFile, promptContext)
@@ -126,7 +128,8 @@ scopeconfig: []
 
 	t.Run("when user confirms, entry should be appended to given ignore file", func(t *testing.T) {
 		promptContext := prompt.NewPromptContext(true, prompter)
-		prompter.EXPECT().Confirm("Do you want to add this entry in talismanrc ?").Return(true)
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice)
+		prompter.EXPECT().Input(gomock.Any(), "").Return("").Times(3)
 
 		results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
 
@@ -134,8 +137,6 @@ scopeconfig: []
 - filename: existing.pem
   checksum: 123444ddssa75333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac
   ignore_detectors: []
-scopeconfig: []
-fileignoreconfig:
 - filename: some_file.pem
   checksum: 87139cc4d975333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac
   ignore_detectors: []
@@ -154,7 +155,8 @@ scopeconfig: []
 		assert.NoError(t, err)
 
 		promptContext := prompt.NewPromptContext(true, prompter)
-		prompter.EXPECT().Confirm("Do you want to add this entry in talismanrc ?").Return(true).Times(2)
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice).Times(2)
+		prompter.EXPECT().Input(gomock.Any(), "").Return("").Times(6)
 
 		results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
 		results.Fail("another.pem", "filecontent", "password", []string{})
@@ -166,7 +168,6 @@ scopeconfig: []
 - filename: another.pem
   checksum: 117e23557c02cbd472854ebce4933d6daec1fd207971286f6ffc9f1774c1a83b
   ignore_detectors: []
-scopeconfig: []
 `
 		results.Report(fs, ignoreFile, promptContext)
 		bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
@@ -179,3 +180,263 @@ scopeconfig: []
 	err = fs.Remove(ignoreFile)
 	assert.NoError(t, err)
 }
+
+func TestTalismanRCSuggestionAsksForTheExpiryWhenADefaultIsConfigured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	results := NewDetectionResults()
+	results.SuggestExpiry("2027-01-16")
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+	results.Fail("another.pem", "filecontent", "password", []string{})
+
+	prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice).Times(2)
+	prompter.EXPECT().Input("When should the entry for some_file.pem expire? (2006-01-02)", "2027-01-16").Return("2026-12-01")
+	prompter.EXPECT().Input("When should the entry for another.pem expire? (2006-01-02)", "2027-01-16").Return("soon")
+	prompter.EXPECT().Input(gomock.Any(), "").Return("").Times(6)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `fileignoreconfig:
+- filename: some_file.pem
+  checksum: 87139cc4d975333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac
+  ignore_detectors: []
+  expires: "2026-12-01"
+- filename: another.pem
+  checksum: 117e23557c02cbd472854ebce4933d6daec1fd207971286f6ffc9f1774c1a83b
+  ignore_detectors: []
+  expires: "2027-01-16"
+`, string(bytesFromFile))
+}
+
+func TestTalismanRCSuggestionAsksForTheJustificationOfEveryEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	results := NewDetectionResults()
+	results.RequireReason(true)
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+	results.Fail("another.pem", "filecontent", "password", []string{})
+
+	prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice).Times(2)
+	prompter.EXPECT().Input("Why should some_file.pem be ignored?", "").Return("test fixture")
+	prompter.EXPECT().Input("Who owns this entry?", "").Return("payments-team")
+	prompter.EXPECT().Input("Which ticket tracks this entry?", "").Return("SEC-42")
+	prompter.EXPECT().Input("Why should another.pem be ignored?", "").Return(" ")
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `fileignoreconfig:
+- filename: some_file.pem
+  checksum: 87139cc4d975333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac
+  ignore_detectors: []
+  reason: test fixture
+  owner: payments-team
+  ticket: SEC-42
+`, string(bytesFromFile), "Expected the entry without a reason not to be added")
+}
+
+func TestIgnoresRecordTheJustificationOfTheirEntry(t *testing.T) {
+	results := NewDetectionResults()
+	justification := Justification{Reason: "test fixture", Owner: "payments-team", Ticket: "SEC-42"}
+
+	results.IgnoreFrom("some_file.pem", "filename", ".talismanrc", justification)
+	results.Fail("other_file.pem", "filename", "Bomb", []string{})
+	report, err := json.Marshal(results)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(report), `"ignore_list":[{"type":"filename","message":"Ignored as configured in .talismanrc","commits":[],"justification":{"reason":"test fixture","owner":"payments-team","ticket":"SEC-42"}}]`)
+	assert.Contains(t, string(report), `"failure_list":[{"type":"filename","message":"Bomb","commits":[]}]`)
+}
+
+func TestTriageCanIgnoreASingleFindingOfAFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	results := NewDetectionResults()
+	addition := gitrepo.NewAddition("some_file.pem", []byte("Bomb"))
+	results.ChecksumContentOf([]gitrepo.Addition{addition})
+	results.Fail("some_file.pem", "filename", "Bad name", []string{})
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+
+	options := []string{ignoreFindingChoice, ignoreFileChoice, keepFailingChoice, abortChoice}
+	gomock.InOrder(
+		prompter.EXPECT().Select("What should be done about this finding?", options, keepFailingChoice).Return(ignoreFindingChoice),
+		prompter.EXPECT().Select("What should be done about this finding?", options, keepFailingChoice).Return(keepFailingChoice),
+	)
+	prompter.EXPECT().Input(gomock.Any(), "").Return("").Times(3)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `fileignoreconfig:
+- filename: some_file.pem
+  checksum: `+ContentChecksum([]gitrepo.Addition{addition})+`
+  ignore_detectors:
+  - filename
+`, string(bytesFromFile))
+}
+
+func TestAnIgnoredFindingDoesNotHideSecretsAddedToTheFileLater(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	results := NewDetectionResults()
+	results.ChecksumContentOf([]gitrepo.Addition{gitrepo.NewAddition("config.yml", []byte("password: hunter2\n"))})
+	results.FailWithValue("config.yml", "filecontent", "Potential secret pattern : password: hunter2", "password: hunter2", []string{})
+
+	prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFindingChoice)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+	assert.NoError(t, err)
+	ignores := NewTalismanRCIgnore(bytesFromFile)
+
+	unchanged := []gitrepo.Addition{gitrepo.NewAddition("config.yml", []byte("password: hunter2\n"))}
+	unchangedResults := NewDetectionResults()
+	NewPatternDetector().Test(unchanged, ignores, unchangedResults)
+	assert.True(t, unchangedResults.Successful(), "Expected the ignored finding to be ignored while the file is unchanged")
+
+	changed := []gitrepo.Addition{gitrepo.NewAddition("config.yml", []byte("password: hunter2\n<ConsumerKey>alksjdhfkjaklsdhflk12345adskjf</ConsumerKey>\n"))}
+	changedResults := NewDetectionResults()
+	NewPatternDetector().Test(changed, ignores, changedResults)
+	assert.True(t, changedResults.HasFailures(), "Expected a secret added to the file later to fail")
+}
+
+func TestIgnoringAFindingKeepsTheOtherFindingsOfTheFileFailing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	additions := []gitrepo.Addition{gitrepo.NewAddition("config.yml", []byte("password: hunter2\n<ConsumerKey>alksjdhfkjaklsdhflk12345adskjf</ConsumerKey>\n"))}
+	results := NewDetectionResults()
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+	results.ChecksumContentOf(additions)
+	failures := results.GetFailures("config.yml")
+	assert.Len(t, failures, 2)
+
+	gomock.InOrder(
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFindingChoice),
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(keepFailingChoice),
+	)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+	assert.NoError(t, err)
+	rescanned := NewDetectionResults()
+	NewPatternDetector().Test(additions, NewTalismanRCIgnore(bytesFromFile), rescanned)
+
+	assert.Equal(t, []Details{failures[1]}, rescanned.GetFailures("config.yml"), "Expected only the ignored finding to be ignored")
+}
+
+func TestTriageChecksumsTheContentThatTheFailureWasFoundIn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	failing := gitrepo.NewAddition("config.yml", []byte("password: hunter2\n"))
+	failing.Commits = []string{"first"}
+	fixed := gitrepo.NewAddition("config.yml", []byte("password: ${DB_PASSWORD}\n"))
+	fixed.Commits = []string{"second"}
+	results := NewDetectionResults()
+	results.ChecksumContentOf([]gitrepo.Addition{failing, fixed})
+	results.FailWithValue("config.yml", "filecontent", "Potential secret pattern : password: hunter2", "password: hunter2", []string{"first"})
+
+	prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice)
+	prompter.EXPECT().Input(gomock.Any(), "").Return("").Times(3)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(bytesFromFile), "checksum: "+ContentChecksum([]gitrepo.Addition{failing}))
+}
+
+func TestTriageCanAddTheValueOfAFindingToTheAllowlist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	err := afero.WriteFile(fs, ignoreFile, []byte("placeholderconfig:\n  action: warn # placeholders are reviewed\n"), 0644)
+	assert.NoError(t, err)
+	results := NewDetectionResults()
+	results.ChecksumContentOf([]gitrepo.Addition{gitrepo.NewAddition("config.yml", []byte("db:\n  user: admin\n  password: hunter2\n"))})
+	results.FailWithValue("config.yml", "filecontent", "Potential secret pattern : password: hunter2", "password: hunter2", []string{})
+
+	options := []string{ignoreFindingChoice, ignoreFileChoice, allowlistValueChoice, showLinesChoice, keepFailingChoice, abortChoice}
+	gomock.InOrder(
+		prompter.EXPECT().Select("What should be done about this finding?", options, keepFailingChoice).Return(showLinesChoice),
+		prompter.EXPECT().Select("What should be done about this finding?", options, keepFailingChoice).Return(allowlistValueChoice),
+	)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	bytesFromFile, err := afero.ReadFile(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "placeholderconfig:\n  action: warn # placeholders are reviewed\n  dummy_values:\n  - 'password: hunter2'\n", string(bytesFromFile))
+}
+
+func TestTriageAddsNothingWhenTheUserAborts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	ignoreFile := ".talismanrc"
+	results := NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+	results.Fail("another.pem", "filecontent", "password", []string{})
+
+	gomock.InOrder(
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(ignoreFileChoice),
+		prompter.EXPECT().Select("What should be done about this finding?", gomock.Any(), keepFailingChoice).Return(abortChoice),
+	)
+
+	results.Report(fs, ignoreFile, prompt.NewPromptContext(true, prompter))
+	exists, err := afero.Exists(fs, ignoreFile)
+
+	assert.NoError(t, err)
+	assert.False(t, exists, "Expected nothing to be written when the user aborts")
+}
+
+func TestFailuresAreAttributedToTheRefsTheyWereFoundIn(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail("some_file.pem", "filename", "Bomb", []string{})
+	addition := gitrepo.NewAddition("some_file.pem", []byte("secret"))
+	addition.Refs = []string{"refs/heads/main", "refs/tags/v1"}
+
+	results.AttributeRefs([]gitrepo.Addition{addition, gitrepo.NewAddition("other_file.txt", nil)})
+
+	assert.Equal(t, [][]string{{"some_file.pem\n(refs/heads/main, refs/tags/v1)", "Bomb"}}, results.ReportFileFailures("some_file.pem"))
+}
+
+func TestFailuresAreAttributedToTheCommitsTheyWereFoundIn(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{"0123456789abcdef"})
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{"fedcba9876543210", "0123456789abcdef"})
+
+	assert.Equal(t, [][]string{{"some_file.pem", "Bomb\n(in 0123456, fedcba9)"}}, results.ReportFileFailures("some_file.pem"))
+}
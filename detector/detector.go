@@ -1,7 +1,6 @@
 package detector
 
 import (
-	"os"
 	"talisman/gitrepo"
 )
 
@@ -26,11 +25,21 @@ func NewChain() *Chain {
 }
 
 //DefaultChain returns a DetectorChain with pre-configured detectors
-func DefaultChain() *Chain {
+//The content detectors examine up to jobs additions concurrently. A jobs value that is not positive means DefaultJobs.
+func DefaultChain(jobs int) *Chain {
 	result := NewChain()
 	result.AddDetector(DefaultFileNameDetector())
-	result.AddDetector(NewFileContentDetector())
-	result.AddDetector(NewPatternDetector())
+	result.AddDetector(NewFileContentDetector().WithJobs(jobs))
+	result.AddDetector(NewPatternDetector().WithJobs(jobs))
+	return result
+}
+
+//ContentChain returns a DetectorChain that only examines the content of the additions, for commit messages that have no file name worth checking.
+//The content detectors examine up to jobs additions concurrently. A jobs value that is not positive means DefaultJobs.
+func ContentChain(jobs int) *Chain {
+	result := NewChain()
+	result.AddDetector(NewFileContentDetector().WithJobs(jobs))
+	result.AddDetector(NewPatternDetector().WithJobs(jobs))
 	return result
 }
 
@@ -43,10 +52,6 @@ func (dc *Chain) AddDetector(d Detector) *Chain {
 //Test validates the additions against each detector in the chain.
 //The results are passed in from detector to detector and thus collect all errors from all detectors
 func (dc *Chain) Test(additions []gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
-	wd, _ := os.Getwd()
-	repo := gitrepo.RepoLocatedAt(wd)
-	gitTrackedFilesAsAdditions := repo.TrackedFilesAsAdditions()
-	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, additions...)
 	for _, v := range dc.detectors {
 		v.Test(additions, ignoreConfig, result)
 	}
@@ -0,0 +1,55 @@
+package detector
+
+import (
+	"fmt"
+	"runtime/debug"
+	"talisman/gitrepo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//ErrorCategory is the category under which additions that a detector failed to examine are reported
+const ErrorCategory = "error"
+
+//DetectorErrorPolicy decides whether an addition that a detector failed to examine fails the run
+type DetectorErrorPolicy string
+
+const (
+	//DetectorErrorPolicyFail fails the run for additions that could not be examined. It is the default policy.
+	DetectorErrorPolicyFail DetectorErrorPolicy = "fail"
+	//DetectorErrorPolicyWarn only warns about additions that could not be examined
+	DetectorErrorPolicyWarn DetectorErrorPolicy = "warn"
+)
+
+//report records that the named detector failed to examine the addition at the supplied path, as the policy requires
+func (policy DetectorErrorPolicy) report(path gitrepo.FilePath, commits []string, detectorName string, err error, result *DetectionResults) {
+	message := fmt.Sprintf("The %s detector was unable to examine the file: %v", detectorName, err)
+	logEntry := log.WithFields(log.Fields{
+		"filePath": path,
+		"detector": detectorName,
+		"error":    err,
+		"policy":   policy,
+	})
+	if policy == DetectorErrorPolicyWarn {
+		logEntry.Warn("Warning file as a detector was unable to examine it.")
+		result.Warn(path, ErrorCategory, message, commits)
+		return
+	}
+	logEntry.Error("Failing file as a detector was unable to examine it.")
+	result.Fail(path, ErrorCategory, message, commits)
+}
+
+//examine runs test against the addition, turning a panic into an error,
+//so that one addition a detector trips over is reported rather than crashing the whole run
+func examine(addition gitrepo.Addition, test func(addition gitrepo.Addition) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+				"panic":    recovered,
+			}).Debugf("Recovered from detector panic\n%s", debug.Stack())
+			err = fmt.Errorf("unexpected error: %v", recovered)
+		}
+	}()
+	return test(addition)
+}
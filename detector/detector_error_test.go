@@ -0,0 +1,65 @@
+package detector
+
+import (
+	"errors"
+	"io"
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func unreadableAddition(path string) gitrepo.Addition {
+	return gitrepo.NewLazyAddition(path, []string{"commit"}, func() (io.ReadCloser, error) {
+		return nil, errors.New("object not found")
+	})
+}
+
+func panickingAddition(path string) gitrepo.Addition {
+	return gitrepo.NewLazyAddition(path, []string{"commit"}, func() (io.ReadCloser, error) {
+		panic("unexpected input")
+	})
+}
+
+func TestShouldFailFilesThatDetectorsAreUnableToExamine(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []gitrepo.Addition{unreadableAddition("broken.txt"), gitrepo.NewAddition("fine.txt", []byte("fine"))}
+
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures(), "Expected unexaminable files to fail the run")
+	assert.Equal(t, 1, results.Summary.Types.Errors)
+	assert.Equal(t, []Details{{Category: ErrorCategory, Message: "The pattern detector was unable to examine the file: object not found", Commits: []string{"commit"}}}, results.GetFailures("broken.txt"))
+	assert.Empty(t, results.GetFailures("fine.txt"))
+}
+
+func TestShouldRecoverFromDetectorPanicsForEachFile(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []gitrepo.Addition{panickingAddition("panics.txt"), gitrepo.NewAddition("secret.txt", []byte("password=UnsafePassword"))}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+	NewFileSizeDetector(10).Test(additions, TalismanRCIgnore{}, results)
+
+	failures := results.GetFailures("panics.txt")
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, "The filecontent detector was unable to examine the file: unexpected error: unexpected input", failures[0].Message)
+		assert.Equal(t, "The filesize detector was unable to examine the file: unexpected error: unexpected input", failures[1].Message)
+	}
+	assert.NotEmpty(t, results.GetFailures("secret.txt"), "Expected other files to still be examined")
+}
+
+func TestShouldOnlyWarnAboutUnexaminableFilesWhenPolicyIsWarn(t *testing.T) {
+	results := NewDetectionResults()
+	ignoreConfig := TalismanRCIgnore{DetectorErrorPolicy: DetectorErrorPolicyWarn}
+
+	NewPatternDetector().Test([]gitrepo.Addition{unreadableAddition("broken.txt")}, ignoreConfig, results)
+
+	assert.False(t, results.HasFailures(), "Expected unexaminable files not to fail the run")
+	assert.True(t, results.HasWarnings(), "Expected unexaminable files to be warned about")
+}
+
+func TestShouldReadDetectorErrorPolicyFromTalismanRC(t *testing.T) {
+	talismanRC := NewTalismanRCIgnore([]byte("detector_error_policy: warn\n"))
+
+	assert.Equal(t, DetectorErrorPolicyWarn, talismanRC.DetectorErrorPolicy)
+}
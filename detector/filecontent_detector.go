@@ -3,8 +3,6 @@ package detector
 import (
 	"fmt"
 	"regexp"
-	"strings"
-	"sync"
 	"talisman/gitrepo"
 
 	log "github.com/Sirupsen/logrus"
@@ -16,6 +14,7 @@ type FileContentDetector struct {
 	base64Detector     *Base64Detector
 	hexDetector        *HexDetector
 	creditCardDetector *CreditCardDetector
+	jobs               int
 }
 
 func NewFileContentDetector() *FileContentDetector {
@@ -31,6 +30,12 @@ func (fc *FileContentDetector) AggressiveMode() *FileContentDetector {
 	return fc
 }
 
+//WithJobs sets the number of additions that are examined concurrently
+func (fc *FileContentDetector) WithJobs(jobs int) *FileContentDetector {
+	fc.jobs = jobs
+	return fc
+}
+
 type contentType int
 
 const (
@@ -69,6 +74,8 @@ type content struct {
 	path        gitrepo.FilePath
 	contentType contentType
 	results     []string
+	commits     []string
+	err         error
 }
 
 func (fc *FileContentDetector) Test(additions []gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
@@ -90,106 +97,99 @@ func (fc *FileContentDetector) Test(additions []gitrepo.Addition, ignoreConfig T
 		},
 	}
 	cc := NewChecksumCompare(additions, ignoreConfig)
+	placeholders := NewPlaceholderClassifier(ignoreConfig.PlaceholderConfig)
+	scan := *fc
+	scan.base64Detector = fc.base64Detector.withWordCheck(NewWordCheck(ignoreConfig.DictionaryConfig))
 	re := regexp.MustCompile(`(?i)checksum[ \t]*:[ \t]*[0-9a-fA-F]+`)
 
 	contents := make(chan content, 512)
-	ignoredFilePaths := make(chan gitrepo.FilePath, len(additions))
-
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(len(additions))
-	for _, addition := range additions {
-		go func(addition gitrepo.Addition) {
-			defer waitGroup.Done()
-			if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition) {
-				ignoredFilePaths <- addition.Path
-				return
-			}
+	ignoredAdditions := make(chan gitrepo.Addition, len(additions))
 
-			if string(addition.Name) == DefaultRCFileName {
-				content := re.ReplaceAllString(string(addition.Data), "")
-				data := []byte(content)
-				addition.Data = data
-			}
-			for _, ct := range contentTypes {
-				contents <- content{
-					name:        addition.Name,
-					path:        addition.Path,
-					contentType: ct.contentType,
-					results:     fc.detectFile(addition.Data, ct.fn),
-				}
+	forEachAddition(additions, fc.jobs, func(addition gitrepo.Addition) error {
+		if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition, "filecontent") {
+			ignoredAdditions <- addition
+			return nil
+		}
+
+		data, err := addition.Content()
+		if err != nil {
+			return err
+		}
+		if string(addition.Name) == DefaultRCFileName {
+			data = []byte(re.ReplaceAllString(string(data), ""))
+		}
+		tokens := addedTokens(addition, tokenizerFor(addition.Name).tokenize(string(data)))
+		for _, ct := range contentTypes {
+			contents <- content{
+				name:        addition.Name,
+				path:        addition.Path,
+				contentType: ct.contentType,
+				results:     scan.detectFile(tokens, ct.fn),
+				commits:     addition.Commits,
 			}
-		}(addition)
-	}
-	go func() {
-		waitGroup.Wait()
-		close(ignoredFilePaths)
+		}
+		return nil
+	}, func(addition gitrepo.Addition, err error) {
+		contents <- content{name: addition.Name, path: addition.Path, commits: addition.Commits, err: err}
+	}, func() {
+		close(ignoredAdditions)
 		close(contents)
-	}()
+	})
 
 	for ignoredChanHasMore, contentChanHasMore := true, true; ignoredChanHasMore || contentChanHasMore; {
 		select {
-		case ignoredFilePath, hasMore := <-ignoredFilePaths:
+		case ignoredAddition, hasMore := <-ignoredAdditions:
 			if !hasMore {
 				ignoredChanHasMore = false
 				continue
 			}
-			processIgnoredFilepath(ignoredFilePath, result)
+			processIgnoredAddition(ignoredAddition, ignoreConfig, result)
 		case c, hasMore := <-contents:
 			if !hasMore {
 				contentChanHasMore = false
 				continue
 			}
-			processContent(c, result)
+			if c.err != nil {
+				ignoreConfig.DetectorErrorPolicy.report(c.path, c.commits, "filecontent", c.err, result)
+				continue
+			}
+			processContent(c, placeholders, result)
 		}
 	}
 }
 
-func processIgnoredFilepath(path gitrepo.FilePath, result *DetectionResults) {
+func processIgnoredAddition(addition gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
+	source := ignoreConfig.SourceOf(addition)
 	log.WithFields(log.Fields{
-		"filePath": path,
+		"filePath": addition.Path,
+		"source":   source,
 	}).Info("Ignoring addition as it was specified to be ignored.")
-	result.Ignore(path, "filecontent")
+	result.IgnoreFrom(addition.Path, "filecontent", source, ignoreConfig.JustificationOf(addition))
 }
 
-func processContent(c content, result *DetectionResults) {
+func processContent(c content, placeholders *PlaceholderClassifier, result *DetectionResults) {
 	for _, res := range c.results {
-		if res != "" {
+		if isPlaceholder, reason := placeholders.Classify(res); isPlaceholder {
+			placeholders.report(c.path, res, reason, c.commits, result)
+		} else if res != "" {
 			log.WithFields(log.Fields{
 				"filePath": c.path,
 			}).Info(c.contentType.getInfo())
 			if string(c.name) == DefaultRCFileName {
-				result.Warn(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), res), []string{})
+				result.Warn(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), res), c.commits)
 			} else {
-				result.Fail(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), res), []string{})
+				result.FailWithValue(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), res), res, c.commits)
 			}
 		}
 	}
 }
 
-func (fc *FileContentDetector) detectFile(data []byte, getResult fn) []string {
-	content := string(data)
-	return fc.checkEachLine(content, getResult)
-}
-
-func (fc *FileContentDetector) checkEachLine(content string, getResult fn) []string {
-	lines := strings.Split(content, "\n")
-	res := []string{}
-	for _, line := range lines {
-		lineResult := fc.checkEachWord(line, getResult)
-		if len(lineResult) > 0 {
-			res = append(res, lineResult...)
-		}
-	}
-	return res
-}
-
-func (fc *FileContentDetector) checkEachWord(line string, getResult fn) []string {
-	words := strings.Fields(line)
+func (fc *FileContentDetector) detectFile(tokens []token, getResult fn) []string {
 	res := []string{}
-	for _, word := range words {
-		wordResult := getResult(fc, word)
-		if wordResult != "" {
-			res = append(res, wordResult)
+	for _, token := range tokens {
+		tokenResult := getResult(fc, token.text)
+		if tokenResult != "" {
+			res = append(res, tokenResult)
 		}
 	}
 	return res
@@ -34,6 +34,16 @@ func TestShouldIgnoreFileIfNeeded(t *testing.T) {
 	assert.True(t, results.Successful(), "Expected file %s to be ignored by pattern", filename)
 }
 
+func TestShouldNotFlagContentOnLinesThatAreNotAdded(t *testing.T) {
+	const awsSecretAccessKey string = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	results := NewDetectionResults()
+	addition := gitrepo.NewAddition("filename", []byte(awsSecretAccessKey+"\nprettySafe\n"))
+	addition.AddedLines = []gitrepo.LineRange{{First: 2, Last: 2}}
+
+	NewFileContentDetector().Test([]gitrepo.Addition{addition}, TalismanRCIgnore{}, results)
+	assert.False(t, results.HasFailures(), "Expected the content that was already committed not to be reported again")
+}
+
 func TestShouldNotFlag4CharSafeText(t *testing.T) {
 	/*This only tell that an input could have been a b64 encoded value, but it does not tell whether or not the
 	input is actually a b64 encoded value. In other words, abcd will match, but it is not necessarily represent
@@ -188,6 +198,16 @@ func TestResultsShouldContainCreditCardNumberIfCreditCardNumberExistInFile(t *te
 	assert.Len(t, results.Results, 1)
 }
 
+func TestShouldNotLeaveTheRepositoryDictionaryOnTheDetector(t *testing.T) {
+	fc := NewFileContentDetector()
+	additions := []gitrepo.Addition{gitrepo.NewAddition("filename", []byte("Kafka"))}
+	ignoreConfig := TalismanRCIgnore{DictionaryConfig: DictionaryConfig{Words: []string{"Kafka"}}}
+
+	fc.Test(additions, ignoreConfig, NewDetectionResults())
+
+	assert.Nil(t, fc.base64Detector.wordCheck, "Expected the dictionary of one run not to be kept for the next")
+}
+
 func getFailureMessages(results *DetectionResults, filePath gitrepo.FilePath) []string {
 	failureMessages := []string{}
 	for _, failureDetails := range results.GetFailures(filePath) {
@@ -80,21 +80,33 @@ func NewFileNameDetector(patterns []*regexp.Regexp) Detector {
 func (fd FileNameDetector) Test(additions []gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
-		if ignoreConfig.Deny(addition, "filename") || cc.IsScanNotRequired(addition){
+		if ignoreConfig.Deny(addition, "filename") || cc.IsScanNotRequired(addition, "filename"){
+			source := ignoreConfig.SourceOf(addition)
 			log.WithFields(log.Fields{
 				"filePath": addition.Path,
+				"source":   source,
 			}).Info("Ignoring addition as it was specified to be ignored.")
-			result.Ignore(addition.Path, "filename")
+			result.IgnoreFrom(addition.Path, "filename", source, ignoreConfig.JustificationOf(addition))
 			continue
 		}
-		for _, pattern := range fd.flagPatterns {
-			if pattern.MatchString(string(addition.Name)) {
-				log.WithFields(log.Fields{
-					"filePath": addition.Path,
-					"pattern":  pattern,
-				}).Info("Failing file as it matched pattern.")
-				result.Fail(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, pattern), addition.Commits)
-			}
+		err := examine(addition, func(addition gitrepo.Addition) error {
+			fd.testName(addition, result)
+			return nil
+		})
+		if err != nil {
+			ignoreConfig.DetectorErrorPolicy.report(addition.Path, addition.Commits, "filename", err, result)
+		}
+	}
+}
+
+func (fd FileNameDetector) testName(addition gitrepo.Addition, result *DetectionResults) {
+	for _, pattern := range fd.flagPatterns {
+		if pattern.MatchString(string(addition.Name)) {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+				"pattern":  pattern,
+			}).Info("Failing file as it matched pattern.")
+			result.Fail(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, pattern), addition.Commits)
 		}
 	}
 }
@@ -23,21 +23,36 @@ func NewFileSizeDetector(size int) Detector {
 func (fd FileSizeDetector) Test(additions []gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
-		if ignoreConfig.Deny(addition, "filesize") || cc.IsScanNotRequired(addition) {
+		if ignoreConfig.Deny(addition, "filesize") || cc.IsScanNotRequired(addition, "filesize") {
+			source := ignoreConfig.SourceOf(addition)
 			log.WithFields(log.Fields{
 				"filePath": addition.Path,
+				"source":   source,
 			}).Info("Ignoring addition as it was specified to be ignored.")
-			result.Ignore(addition.Path, "filesize")
+			result.IgnoreFrom(addition.Path, "filesize", source, ignoreConfig.JustificationOf(addition))
 			continue
 		}
-		size := len(addition.Data)
-		if size > fd.size {
-			log.WithFields(log.Fields{
-				"filePath": addition.Path,
-				"fileSize": size,
-				"maxSize":  fd.size,
-			}).Info("Failing file as it is larger than max allowed file size.")
-			result.Fail(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits)
+		err := examine(addition, func(addition gitrepo.Addition) error {
+			return fd.testSize(addition, result)
+		})
+		if err != nil {
+			ignoreConfig.DetectorErrorPolicy.report(addition.Path, addition.Commits, "filesize", err, result)
 		}
 	}
 }
+
+func (fd FileSizeDetector) testSize(addition gitrepo.Addition, result *DetectionResults) error {
+	size, err := addition.Size()
+	if err != nil {
+		return err
+	}
+	if size > int64(fd.size) {
+		log.WithFields(log.Fields{
+			"filePath": addition.Path,
+			"fileSize": size,
+			"maxSize":  fd.size,
+		}).Info("Failing file as it is larger than max allowed file size.")
+		result.Fail(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package detector
+
+import (
+	"fmt"
+	"talisman/gitrepo"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	//ExpiryCategory is the category under which fileignoreconfig entries that expired, or expire soon, are reported
+	ExpiryCategory = "expiry"
+
+	//ExpiryDateFormat is the format of the expires field of a fileignoreconfig entry
+	ExpiryDateFormat = "2006-01-02"
+
+	//ExpiryActionFail fails the run for every expired fileignoreconfig entry that matches a scanned file. It is the default action.
+	ExpiryActionFail = "fail"
+	//ExpiryActionWarn only warns about expired fileignoreconfig entries
+	ExpiryActionWarn = "warn"
+
+	defaultExpiryNoticeDays = 14
+)
+
+//now returns the current time, and is replaced in tests
+var now = time.Now
+
+//ExpiryConfig configures how fileignoreconfig entries with an expiry date are treated
+type ExpiryConfig struct {
+	//Action decides whether an expired entry fails the run or is only warned about
+	Action string `yaml:"action,omitempty"`
+	//NoticeDays is the number of days before its expiry that an entry is reported as expiring soon
+	NoticeDays int `yaml:"notice_days,omitempty"`
+	//DefaultDays is the number of days after which the entries suggested by talisman expire, if positive
+	DefaultDays int `yaml:"default_days,omitempty"`
+}
+
+//DefaultExpiry returns the expiry date of the entries suggested today, or an empty string if they never expire
+func (config ExpiryConfig) DefaultExpiry() string {
+	if config.DefaultDays <= 0 {
+		return ""
+	}
+	return now().AddDate(0, 0, config.DefaultDays).Format(ExpiryDateFormat)
+}
+
+func (config ExpiryConfig) noticeDays() int {
+	if config.NoticeDays <= 0 {
+		return defaultExpiryNoticeDays
+	}
+	return config.NoticeDays
+}
+
+//expiryDate returns the last day that the entry is honoured on, and whether the entry expires at all.
+//An expiry date that can't be parsed is reported as an error.
+func (i FileIgnoreConfig) expiryDate() (time.Time, bool, error) {
+	if i.Expires == "" {
+		return time.Time{}, false, nil
+	}
+	date, err := time.ParseInLocation(ExpiryDateFormat, i.Expires, time.Local)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("expires %q of %s is not a date such as %s", i.Expires, i.FileName, ExpiryDateFormat)
+	}
+	return date, true, nil
+}
+
+//isExpired answers true once the day the entry expires on has passed.
+//An entry whose expiry date can't be parsed is treated as expired, so that a typo never extends an ignore.
+func (i FileIgnoreConfig) isExpired(at time.Time) bool {
+	date, expires, err := i.expiryDate()
+	if !expires {
+		return false
+	}
+	return err != nil || !at.Before(date.AddDate(0, 0, 1))
+}
+
+//ReportExpiries reports the fileignoreconfig entries that have expired, as the expiry action requires,
+//and warns about the entries that expire within the notice period.
+//Expired entries that match none of the scanned additions are only warned about, as they ignore nothing in this run.
+//Every entry is reported against the .talismanrc file it was read from.
+func (i TalismanRCIgnore) ReportExpiries(additions []gitrepo.Addition, result *DetectionResults) {
+	today := now()
+	for _, config := range append([]TalismanRCIgnore{i}, i.Nested...) {
+		source := config.Source
+		if source == "" {
+			source = DefaultRCFileName
+		}
+		for _, ignore := range config.FileIgnoreConfig {
+			i.ExpiryConfig.report(source, ignore, config.matchesAny(ignore, additions), today, result)
+		}
+	}
+}
+
+//matchesAny answers if the entry of the config matches any of the additions, named relative to the directory of the config
+func (i TalismanRCIgnore) matchesAny(ignore FileIgnoreConfig, additions []gitrepo.Addition) bool {
+	if isEmptyString(ignore.FileName) || ignore.pattern().IsNegation() {
+		return false
+	}
+	for _, addition := range additions {
+		if relative, ok := i.relativeAddition(addition); ok && ignore.pattern().Matches(relative) {
+			return true
+		}
+	}
+	return false
+}
+
+func (config ExpiryConfig) report(source string, ignore FileIgnoreConfig, matchesScanned bool, today time.Time, result *DetectionResults) {
+	date, expires, err := ignore.expiryDate()
+	if !expires {
+		return
+	}
+	reason := ""
+	if ignore.Reason != "" {
+		reason = fmt.Sprintf(" (reason: %s)", ignore.Reason)
+	}
+	logEntry := log.WithFields(log.Fields{
+		"source":   source,
+		"filename": ignore.FileName,
+		"expires":  ignore.Expires,
+	})
+	if err != nil || ignore.isExpired(today) {
+		message := fmt.Sprintf("The fileignoreconfig entry for %s expired on %s%s, and is no longer honoured", ignore.FileName, ignore.Expires, reason)
+		if err != nil {
+			message = fmt.Sprintf("The fileignoreconfig entry for %s is no longer honoured: %v", ignore.FileName, err)
+		}
+		if config.Action == ExpiryActionWarn || !matchesScanned {
+			logEntry.Warn("Warning about expired fileignoreconfig entry.")
+			result.Warn(gitrepo.FilePath(source), ExpiryCategory, message, []string{})
+			return
+		}
+		logEntry.Info("Failing expired fileignoreconfig entry.")
+		result.Fail(gitrepo.FilePath(source), ExpiryCategory, message, []string{})
+		return
+	}
+	if today.AddDate(0, 0, config.noticeDays()).After(date) {
+		logEntry.Info("Warning about fileignoreconfig entry that expires soon.")
+		result.Warn(gitrepo.FilePath(source), ExpiryCategory, fmt.Sprintf("The fileignoreconfig entry for %s expires on %s%s", ignore.FileName, ignore.Expires, reason), []string{})
+	}
+}
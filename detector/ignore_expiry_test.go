@@ -0,0 +1,109 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"talisman/gitrepo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withToday(date string, test func()) {
+	today, _ := time.ParseInLocation(ExpiryDateFormat, date, time.Local)
+	defer func(realNow func() time.Time) { now = realNow }(now)
+	now = func() time.Time { return today.Add(15 * time.Hour) }
+	test()
+}
+
+func TestShouldStopHonouringIgnoresOnceTheyExpire(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte(`fileignoreconfig:
+- filename: expired.pem
+  ignore_detectors: [filename]
+  expires: 2026-10-17
+- filename: today.pem
+  ignore_detectors: [filename]
+  expires: 2026-10-18
+- filename: mistyped.pem
+  ignore_detectors: [filename]
+  expires: 18/10/2026
+`))
+
+	withToday("2026-10-18", func() {
+		assert.False(t, talismanRCIgnore.Deny(gitrepo.NewAddition("expired.pem", nil), "filename"), "Expected an expired entry to no longer be honoured")
+		assert.True(t, talismanRCIgnore.Deny(gitrepo.NewAddition("today.pem", nil), "filename"), "Expected an entry to be honoured on the day it expires")
+		assert.False(t, talismanRCIgnore.Deny(gitrepo.NewAddition("mistyped.pem", nil), "filename"), "Expected an entry with an unparseable expiry to no longer be honoured")
+	})
+}
+
+func TestShouldFailExpiredIgnoresAndWarnAboutThoseThatExpireSoon(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte(`fileignoreconfig:
+- filename: expired.pem
+  ignore_detectors: [filename]
+  expires: 2026-10-01
+  reason: test fixture until the new key service is live
+- filename: soon.pem
+  ignore_detectors: [filename]
+  expires: 2026-10-25
+- filename: later.pem
+  ignore_detectors: [filename]
+  expires: 2027-01-01
+- filename: forever.pem
+  ignore_detectors: [filename]
+`))
+	talismanRCIgnore.Nested = []TalismanRCIgnore{NewTalismanRCIgnore([]byte("fileignoreconfig:\n- filename: key.pem\n  expires: 2026-09-30\n"))}
+	talismanRCIgnore.Nested[0].Source = "team/.talismanrc"
+	talismanRCIgnore.Nested[0].Directory = "team"
+	additions := []gitrepo.Addition{gitrepo.NewAddition("expired.pem", nil), gitrepo.NewAddition("team/key.pem", nil)}
+	results := NewDetectionResults()
+
+	withToday("2026-10-18", func() {
+		talismanRCIgnore.ReportExpiries(additions, results)
+	})
+
+	assert.True(t, results.HasFailures())
+	assert.Equal(t, 2, results.Summary.Types.Expired)
+	assert.Equal(t, []Details{{Category: ExpiryCategory, Message: "The fileignoreconfig entry for expired.pem expired on 2026-10-01 (reason: test fixture until the new key service is live), and is no longer honoured", Commits: []string{}}}, results.GetFailures(".talismanrc"))
+	assert.Equal(t, []Details{{Category: ExpiryCategory, Message: "The fileignoreconfig entry for key.pem expired on 2026-09-30, and is no longer honoured", Commits: []string{}}}, results.GetFailures("team/.talismanrc"))
+	assert.Equal(t, [][]string{{".talismanrc", "The fileignoreconfig entry for soon.pem expires on 2026-10-25"}}, results.ReportFileWarnings(".talismanrc"))
+}
+
+func TestShouldOnlyWarnAboutExpiredIgnoresIfConfigured(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte("fileignoreconfig:\n- filename: expired.pem\n  expires: 2026-10-01\nexpiryconfig:\n  action: warn\n  notice_days: 30\n"))
+	results := NewDetectionResults()
+
+	withToday("2026-10-18", func() {
+		talismanRCIgnore.ReportExpiries([]gitrepo.Addition{gitrepo.NewAddition("expired.pem", nil)}, results)
+	})
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasWarnings())
+}
+
+func TestShouldOnlyWarnAboutExpiredIgnoresThatMatchNoScannedFile(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte("fileignoreconfig:\n- filename: expired.pem\n  expires: 2026-10-01\n"))
+	results := NewDetectionResults()
+
+	withToday("2026-10-18", func() {
+		talismanRCIgnore.ReportExpiries([]gitrepo.Addition{gitrepo.NewAddition("other.txt", nil)}, results)
+	})
+
+	assert.False(t, results.HasFailures(), "Expected an entry that ignores nothing in this run not to fail it")
+	assert.Equal(t, [][]string{{".talismanrc", "The fileignoreconfig entry for expired.pem expired on 2026-10-01, and is no longer honoured"}}, results.ReportFileWarnings(".talismanrc"))
+}
+
+func TestShouldNotSuggestIgnoringTheTalismanRCOfAnExpiredEntry(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail(".talismanrc", ExpiryCategory, "The fileignoreconfig entry for expired.pem expired on 2026-10-01, and is no longer honoured", []string{})
+	results.Fail("expired.pem", "filename", "The file name \"expired.pem\" failed checks against the pattern ^.+\\.pem$", []string{})
+
+	assert.False(t, results.getResultDetailsForFilePath(".talismanrc").mayBeIgnored())
+	assert.True(t, results.getResultDetailsForFilePath("expired.pem").mayBeIgnored())
+}
+
+func TestShouldSuggestEntriesThatExpireAfterTheDefaultNumberOfDays(t *testing.T) {
+	withToday("2026-10-18", func() {
+		assert.Equal(t, "2027-01-16", ExpiryConfig{DefaultDays: 90}.DefaultExpiry())
+		assert.Equal(t, "", ExpiryConfig{}.DefaultExpiry())
+	})
+}
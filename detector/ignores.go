@@ -3,8 +3,10 @@ package detector
 import (
 	"gopkg.in/yaml.v2"
 	"log"
+	"path"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"talisman/gitrepo"
@@ -41,17 +43,57 @@ type Ignore struct {
 
 type FileIgnoreConfig struct {
 	FileName        string `yaml:"filename"`
-	Checksum        string `yaml:"checksum"`
+	Checksum        string `yaml:"checksum,omitempty"`
 	IgnoreDetectors []string `yaml:"ignore_detectors"`
+	//Expires is the last day, formatted as ExpiryDateFormat, that the entry is honoured on. Entries without it never expire.
+	Expires string `yaml:"expires,omitempty"`
+	//Reason explains why the file is ignored
+	Reason string `yaml:"reason,omitempty"`
+	//Owner is the person or team that is accountable for the file being ignored
+	Owner string `yaml:"owner,omitempty"`
+	//Ticket refers to the issue that tracks the file being ignored
+	Ticket string `yaml:"ticket,omitempty"`
 }
 
+//ScopeConfig names a scope whose files are not scanned.
+//A custom scope lists its own paths, while the paths of a built-in scope are known to talisman.
 type ScopeConfig struct {
 	ScopeName string `yaml:"scope"`
+	//Paths are the patterns of the files of a custom scope
+	Paths []string `yaml:"paths,omitempty"`
+	//IgnoreDetectors are the detectors that skip the files of the scope. The files are skipped by every detector if there are none.
+	IgnoreDetectors []string `yaml:"ignore_detectors,omitempty"`
 }
 
 type TalismanRCIgnore struct {
-	FileIgnoreConfig []FileIgnoreConfig `yaml:"fileignoreconfig"`
-	ScopeConfig      []ScopeConfig      `yaml:"scopeconfig"`
+	FileIgnoreConfig  []FileIgnoreConfig `yaml:"fileignoreconfig"`
+	ScopeConfig       []ScopeConfig      `yaml:"scopeconfig"`
+	PlaceholderConfig PlaceholderConfig  `yaml:"placeholderconfig,omitempty"`
+	DictionaryConfig  DictionaryConfig   `yaml:"dictionaryconfig,omitempty"`
+	ExpiryConfig      ExpiryConfig       `yaml:"expiryconfig,omitempty"`
+	//DetectorErrorPolicy decides whether files that a detector was unable to examine fail the run
+	DetectorErrorPolicy DetectorErrorPolicy `yaml:"detector_error_policy,omitempty"`
+	//RequireIgnoreReason makes fileignoreconfig entries without a reason invalid
+	RequireIgnoreReason bool `yaml:"require_ignore_reason,omitempty"`
+	//MandatoryDetectors lists the detectors that no file can be ignored for
+	MandatoryDetectors []string `yaml:"mandatory_detectors,omitempty"`
+	//ForbiddenIgnores lists patterns of files that are examined by every detector, however they are configured to be ignored
+	ForbiddenIgnores []string `yaml:"forbidden_ignores,omitempty"`
+	//CustomPatterns lists regular expressions of secrets that the content of every file is checked for, along with the built in ones
+	CustomPatterns []string `yaml:"custom_patterns,omitempty"`
+	//Locked lists the settings of an organisation policy that the user-global and repository .talismanrc files cannot change
+	Locked []string `yaml:"locked,omitempty"`
+	//Source is the path of the .talismanrc file the config was read from, relative to the repository root.
+	//It is empty for the root .talismanrc.
+	Source string `yaml:"-"`
+	//Directory is the directory that the patterns of the config are relative to. It is empty for the root .talismanrc.
+	Directory string `yaml:"-"`
+	//Nested holds the configs read from the .talismanrc files of subdirectories, deepest first
+	Nested []TalismanRCIgnore `yaml:"-"`
+
+	scopeMap map[string][]string
+	//indexed are the files of the index, which glob entries are checksummed over along with the additions
+	indexed []gitrepo.Addition
 }
 
 func (ignore TalismanRCIgnore) IsEmpty() bool {
@@ -66,6 +108,30 @@ func ReadConfigFromRCFile(repoFileRead func(string) ([]byte, error)) TalismanRCI
 	return NewTalismanRCIgnore(fileContents)
 }
 
+//ReadConfigFromRCFiles reads the root .talismanrc, along with the supplied .talismanrc files of subdirectories.
+//The patterns of a nested .talismanrc are relative to its directory, and it only applies to files within that directory.
+//For every file, the deepest .talismanrc with a fileignoreconfig entry that matches the file decides how the file is ignored.
+func ReadConfigFromRCFiles(repoFileRead func(string) ([]byte, error), rcFiles []string) TalismanRCIgnore {
+	talismanRCIgnore := ReadConfigFromRCFile(repoFileRead)
+	for _, rcFile := range rcFiles {
+		if path.Dir(rcFile) == "." {
+			continue
+		}
+		fileContents, err := repoFileRead(rcFile)
+		if err != nil {
+			log.Printf("error reading %s: %v", rcFile, err)
+			continue
+		}
+		nested := NewTalismanRCIgnore(fileContents)
+		nested.Source = rcFile
+		nested.Directory = path.Dir(rcFile)
+		talismanRCIgnore.Nested = append(talismanRCIgnore.Nested, nested)
+	}
+	sort.SliceStable(talismanRCIgnore.Nested, func(i, j int) bool {
+		return strings.Count(talismanRCIgnore.Nested[i].Directory, "/") > strings.Count(talismanRCIgnore.Nested[j].Directory, "/")
+	})
+	return talismanRCIgnore
+}
 
 func NewTalismanRCIgnore(fileContents []byte) (TalismanRCIgnore) {
 	talismanRCIgnore := TalismanRCIgnore{}
@@ -92,9 +158,77 @@ func NewIgnore(pattern string, comment string) Ignore {
 	}
 }
 
+//SourceOf returns the path of the .talismanrc file that decides how the addition is ignored
+func (i TalismanRCIgnore) SourceOf(addition gitrepo.Addition) string {
+	config, _ := i.configFor(addition)
+	if config.Source == "" {
+		return DefaultRCFileName
+	}
+	return config.Source
+}
+
+//JustificationOf returns the justification of the unexpired fileignoreconfig entry that decides how the addition is ignored.
+//When several entries match, the last of them is used, and a negation leaves no justification.
+func (i TalismanRCIgnore) JustificationOf(addition gitrepo.Addition) Justification {
+	config, relative := i.configFor(addition)
+	justification := Justification{}
+	for _, ignore := range config.FileIgnoreConfig {
+		if !isEmptyString(ignore.FileName) && !ignore.isExpired(now()) && ignore.pattern().Matches(relative) {
+			justification = Justification{}
+			if !ignore.pattern().IsNegation() {
+				justification = Justification{Reason: ignore.Reason, Owner: ignore.Owner, Ticket: ignore.Ticket, Expires: ignore.Expires}
+			}
+		}
+	}
+	return justification
+}
+
+//configFor returns the config that decides how the addition is ignored, along with the addition as seen from the directory of that config.
+//The deepest nested config with an entry that matches the addition decides, falling back to the root config.
+func (i TalismanRCIgnore) configFor(addition gitrepo.Addition) (TalismanRCIgnore, gitrepo.Addition) {
+	for _, nested := range i.Nested {
+		if relative, ok := nested.relativeAddition(addition); ok && nested.hasEntryFor(relative) {
+			return nested, relative
+		}
+	}
+	return i, addition
+}
+
+//relativeAddition returns the addition with its path relative to the directory of the config, if the addition lies within that directory
+func (i TalismanRCIgnore) relativeAddition(addition gitrepo.Addition) (gitrepo.Addition, bool) {
+	if i.Directory == "" {
+		return addition, true
+	}
+	prefix := i.Directory + "/"
+	if !strings.HasPrefix(string(addition.Path), prefix) {
+		return addition, false
+	}
+	relative := addition
+	relative.Path = gitrepo.FilePath(strings.TrimPrefix(string(addition.Path), prefix))
+	return relative, true
+}
+
+//hasEntryFor answers if an unexpired entry of the config matches the addition, including a negation that takes it back out
+func (i TalismanRCIgnore) hasEntryFor(addition gitrepo.Addition) bool {
+	for _, ignore := range i.FileIgnoreConfig {
+		if !isEmptyString(ignore.FileName) && !ignore.isExpired(now()) && ignore.pattern().Matches(addition) {
+			return true
+		}
+	}
+	return false
+}
+
+//pattern returns the gitignore-style pattern of the entry
+func (i FileIgnoreConfig) pattern() gitrepo.Pattern {
+	return gitrepo.NewPattern(i.FileName)
+}
+
+//isEffective answers if the entry applies to the named detector, whatever the content. A negation applies to every detector.
+//An entry with a checksum only applies while the content matches it, which ChecksumCompare answers.
 func (i FileIgnoreConfig) isEffective(detectorName string) bool {
 	return !isEmptyString(i.FileName) &&
-		contains(i.IgnoreDetectors, detectorName)
+		((contains(i.IgnoreDetectors, detectorName) && i.Checksum == "") || i.pattern().IsNegation()) &&
+		!i.isExpired(now())
 }
 
 //NewIgnores builds a new Ignores with the patterns specified in the ignoreSpecs
@@ -121,22 +255,20 @@ func (i TalismanRCIgnore) Accept(addition gitrepo.Addition, detectorName string)
 	return !i.Deny(addition, detectorName)
 }
 
+//IgnoreAdditionsByScope returns the additions that no scope of the config ignores for every detector.
+//Scopes of a nested .talismanrc only apply to the files within its directory.
+//Files that match a forbidden ignore are never ignored by scope.
+//Files of a scope that only some detectors skip, or of any scope when some detectors are mandatory, are left to Deny.
 func IgnoreAdditionsByScope(additions []gitrepo.Addition, rcConfigIgnores TalismanRCIgnore, scopeMap map[string][]string) []gitrepo.Addition {
-	var applicableScopeFileNames []string
-	if rcConfigIgnores.ScopeConfig != nil {
-		for _, scope := range rcConfigIgnores.ScopeConfig {
-			if len(scopeMap[scope.ScopeName]) > 0 {
-				applicableScopeFileNames = append(applicableScopeFileNames, scopeMap[scope.ScopeName]...)
-			}
-		}
-	}
 	var result []gitrepo.Addition
 	for _, addition := range additions {
+		if rcConfigIgnores.isForbiddenIgnore(addition) || len(rcConfigIgnores.MandatoryDetectors) > 0 {
+			result = append(result, addition)
+			continue
+		}
 		isFilePresentInScope := false
-		for _, fileName := range applicableScopeFileNames {
-			if addition.Matches(fileName) {
-				isFilePresentInScope = true
-			}
+		for _, scope := range rcConfigIgnores.scopesOf(addition, scopeMap) {
+			isFilePresentInScope = isFilePresentInScope || len(scope.IgnoreDetectors) == 0
 		}
 		if !isFilePresentInScope {
 			result = append(result, addition)
@@ -144,13 +276,80 @@ func IgnoreAdditionsByScope(additions []gitrepo.Addition, rcConfigIgnores Talism
 	}
 	return result
 }
+
+//WithScopes returns the config along with the paths of the built-in scopes, so that the scopes which only skip some detectors can be applied by Deny
+func (i TalismanRCIgnore) WithScopes(scopeMap map[string][]string) TalismanRCIgnore {
+	i.scopeMap = scopeMap
+	return i
+}
+
+//WithIndexedFiles returns the config along with the files of the index, so that the checksum of an entry covers every file it matches,
+//rather than only the ones that are changed
+func (i TalismanRCIgnore) WithIndexedFiles(indexed []gitrepo.Addition) TalismanRCIgnore {
+	i.indexed = indexed
+	return i
+}
+
+//scopesOf returns the scopes of the config and its nested configs that the addition belongs to.
+//Scopes of a nested config only apply to the files within its directory.
+func (i TalismanRCIgnore) scopesOf(addition gitrepo.Addition, scopeMap map[string][]string) []ScopeConfig {
+	scopes := i.ownScopesOf(addition, scopeMap)
+	for _, nested := range i.Nested {
+		if relative, ok := nested.relativeAddition(addition); ok {
+			scopes = append(scopes, nested.ownScopesOf(relative, scopeMap)...)
+		}
+	}
+	return scopes
+}
+
+func (i TalismanRCIgnore) ownScopesOf(addition gitrepo.Addition, scopeMap map[string][]string) []ScopeConfig {
+	var result []ScopeConfig
+	for _, scope := range i.ScopeConfig {
+		if addition.MatchesPatterns(scope.paths(scopeMap)) {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+//paths returns the patterns of the files of the scope, which a custom scope lists itself
+func (scope ScopeConfig) paths(scopeMap map[string][]string) []string {
+	if len(scope.Paths) > 0 {
+		return scope.Paths
+	}
+	return scopeMap[scope.ScopeName]
+}
+
+//isSkippedByScope answers if the addition belongs to a scope that the named detector skips.
+//A scope that names no detectors is skipped by all of them.
+func (i TalismanRCIgnore) isSkippedByScope(addition gitrepo.Addition, detectorName string) bool {
+	for _, scope := range i.scopesOf(addition, i.scopeMap) {
+		if len(scope.IgnoreDetectors) == 0 || contains(scope.IgnoreDetectors, detectorName) {
+			return true
+		}
+	}
+	return false
+}
+
 //Deny answers true if the Addition.Path is configured to be ignored and not checked by the detectors
 func (i TalismanRCIgnore) Deny(addition gitrepo.Addition, detectorName string) bool {
-	result := false
-	for _, pattern := range i.effectiveRules(detectorName) {
-		result = result || addition.Matches(pattern)
+	if !i.mayBeIgnored(addition, detectorName) {
+		return false
 	}
-	return result
+	if i.isSkippedByScope(addition, detectorName) {
+		return true
+	}
+	config, relative := i.configFor(addition)
+	return relative.MatchesPatterns(config.effectiveRules(detectorName))
+}
+
+//mayBeIgnored answers false if the detector is mandatory, or the addition matches a forbidden ignore
+func (i TalismanRCIgnore) mayBeIgnored(addition gitrepo.Addition, detectorName string) bool {
+	return !contains(i.MandatoryDetectors, detectorName) && !i.isForbiddenIgnore(addition)
+}
+
+func (i TalismanRCIgnore) isForbiddenIgnore(addition gitrepo.Addition) bool {
+	return addition.MatchesPatterns(i.ForbiddenIgnores)
 }
 
 func (i TalismanRCIgnore) effectiveRules(detectorName string) []string {
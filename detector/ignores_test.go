@@ -98,6 +98,26 @@ func TestIgnoreAdditionsByScope(t *testing.T) {
 	assert.NotContains(t, filteredAdditions, file5)
 }
 
+func TestIgnoreAdditionsByCustomScope(t *testing.T) {
+	generated := testAddition("gen/api.pb.go")
+	handwritten := testAddition("api/handler.go")
+	talismanRCIgnore := NewTalismanRCIgnore([]byte("scopeconfig:\n- scope: generated\n  paths: [gen/]\n"))
+
+	filteredAdditions := IgnoreAdditionsByScope([]gitrepo.Addition{generated, handwritten}, talismanRCIgnore, map[string][]string{})
+
+	assert.Equal(t, []gitrepo.Addition{handwritten}, filteredAdditions)
+}
+
+func TestScopeWithIgnoreDetectorsIsOnlySkippedByThoseDetectors(t *testing.T) {
+	lockFile := testAddition("Cargo.lock")
+	talismanRCIgnore := NewTalismanRCIgnore([]byte("scopeconfig:\n- scope: rust\n  ignore_detectors: [filecontent]\n")).
+		WithScopes(map[string][]string{"rust": {"Cargo.lock"}})
+
+	assert.Equal(t, []gitrepo.Addition{lockFile}, IgnoreAdditionsByScope([]gitrepo.Addition{lockFile}, talismanRCIgnore, talismanRCIgnore.scopeMap))
+	assert.True(t, talismanRCIgnore.Deny(lockFile, "filecontent"), "Expected the scope to be skipped by the filecontent detector")
+	assert.False(t, talismanRCIgnore.Deny(lockFile, "filesize"), "Expected the scope to be examined by the filesize detector")
+}
+
 //Need to work on this test case as it deals with comments and talismanrc does not deal in comments
 //func TestCommentPatterns(t *testing.T) {
 //	assertAccepts("foo # some comment", "bar", t)
@@ -167,3 +187,76 @@ func SingleIgnore(pattern string, comment string, ignoredDetectors ...string) Ig
 		ignoredDetectors: ignoredDetectors,
 	}}}
 }
+
+func TestNestedTalismanRCPatternsAreRelativeToItsDirectory(t *testing.T) {
+	talismanRCIgnore := readTalismanRCs(map[string]string{
+		"team/.talismanrc": "fileignoreconfig:\n- filename: private.pem\n  ignore_detectors: [filename]\n",
+	})
+
+	assert.True(t, talismanRCIgnore.Deny(testAddition("team/private.pem"), "filename"), "Expected nested .talismanrc to ignore files in its directory")
+	assert.False(t, talismanRCIgnore.Deny(testAddition("private.pem"), "filename"), "Expected nested .talismanrc not to ignore files outside its directory")
+	assert.False(t, talismanRCIgnore.Deny(testAddition("otherteam/private.pem"), "filename"), "Expected nested .talismanrc not to ignore files outside its directory")
+	assert.Equal(t, "team/.talismanrc", talismanRCIgnore.SourceOf(testAddition("team/private.pem")))
+	assert.Equal(t, ".talismanrc", talismanRCIgnore.SourceOf(testAddition("private.pem")))
+}
+
+func TestDeepestTalismanRCWithAMatchingEntryDecides(t *testing.T) {
+	talismanRCIgnore := readTalismanRCs(map[string]string{
+		".talismanrc":               "fileignoreconfig:\n- filename: team/a/*.pem\n  ignore_detectors: [filename, filecontent]\n- filename: team/b/*.pem\n  ignore_detectors: [filename]\n",
+		"team/.talismanrc":          "fileignoreconfig:\n- filename: a/*.pem\n  ignore_detectors: [filecontent]\n",
+		"team/a/deeper/.talismanrc": "fileignoreconfig:\n- filename: other.txt\n  ignore_detectors: [filename]\n",
+	})
+
+	assert.False(t, talismanRCIgnore.Deny(testAddition("team/a/private.pem"), "filename"), "Expected the entry of team/.talismanrc to override the root")
+	assert.True(t, talismanRCIgnore.Deny(testAddition("team/a/private.pem"), "filecontent"))
+	assert.Equal(t, "team/.talismanrc", talismanRCIgnore.SourceOf(testAddition("team/a/private.pem")))
+	assert.True(t, talismanRCIgnore.Deny(testAddition("team/b/private.pem"), "filename"), "Expected the root to decide when no nested entry matches")
+	assert.Equal(t, ".talismanrc", talismanRCIgnore.SourceOf(testAddition("team/b/private.pem")))
+}
+
+func TestNestedTalismanRCScopesOnlyApplyWithinItsDirectory(t *testing.T) {
+	talismanRCIgnore := readTalismanRCs(map[string]string{
+		"service/.talismanrc": "scopeconfig:\n- scope: go\n",
+	})
+	additions := []gitrepo.Addition{testAddition("service/go.sum"), testAddition("go.sum")}
+
+	remaining := IgnoreAdditionsByScope(additions, talismanRCIgnore, map[string][]string{"go": {"go.sum"}})
+
+	assert.Equal(t, []gitrepo.Addition{testAddition("go.sum")}, remaining)
+}
+
+func readTalismanRCs(rcFiles map[string]string) TalismanRCIgnore {
+	var paths []string
+	for path := range rcFiles {
+		paths = append(paths, path)
+	}
+	return ReadConfigFromRCFiles(func(path string) ([]byte, error) {
+		return []byte(rcFiles[path]), nil
+	}, paths)
+}
+
+func TestShouldReturnTheJustificationOfTheEntryThatIgnoresAFile(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte(`fileignoreconfig:
+- filename: "*.pem"
+  ignore_detectors: [filename]
+  reason: test fixtures
+  owner: payments-team
+  ticket: SEC-42
+`))
+
+	assert.Equal(t, Justification{Reason: "test fixtures", Owner: "payments-team", Ticket: "SEC-42"}, talismanRCIgnore.JustificationOf(gitrepo.NewAddition("test/private.pem", nil)))
+	assert.Equal(t, Justification{}, talismanRCIgnore.JustificationOf(gitrepo.NewAddition("id_rsa", nil)))
+}
+
+func TestNegatedEntryTakesFilesBackOutOfEarlierEntries(t *testing.T) {
+	talismanRCIgnore := NewTalismanRCIgnore([]byte(`fileignoreconfig:
+- filename: "test/**/*.pem"
+  ignore_detectors: [filename]
+  reason: test fixtures
+- filename: "!test/integration/"
+`))
+
+	assert.True(t, talismanRCIgnore.Deny(testAddition("test/unit/keys/private.pem"), "filename"))
+	assert.False(t, talismanRCIgnore.Deny(testAddition("test/integration/private.pem"), "filename"))
+	assert.Equal(t, Justification{}, talismanRCIgnore.JustificationOf(testAddition("test/integration/private.pem")))
+}
@@ -0,0 +1,51 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"talisman/gitrepo"
+)
+
+//LegacyIgnoreFileName is the name of the line based ignore file that .talismanrc replaced, and that is no longer read
+const LegacyIgnoreFileName string = ".talismanignore"
+
+//ReadLegacyIgnores reads the patterns of a .talismanignore file, along with the detectors named in their "# ignore:" comments
+func ReadLegacyIgnores(fileContents []byte) Ignores {
+	return NewIgnores(strings.Split(string(fileContents), "\n")...)
+}
+
+//FileIgnoreConfigs converts the patterns into fileignoreconfig entries, with the checksum of the content of the additions they match.
+//A pattern whose comment names detectors only ignores those detectors. The patterns that match no addition are returned separately.
+func (i Ignores) FileIgnoreConfigs(additions []gitrepo.Addition) ([]FileIgnoreConfig, []string) {
+	var configs []FileIgnoreConfig
+	var unmatched []string
+	for _, ignore := range i.patterns {
+		if isEmptyString(ignore.pattern) {
+			continue
+		}
+		var matching []gitrepo.Addition
+		for _, addition := range additions {
+			if addition.Matches(ignore.pattern) {
+				matching = append(matching, addition)
+			}
+		}
+		if len(matching) == 0 {
+			unmatched = append(unmatched, ignore.pattern)
+			continue
+		}
+		ignoredDetectors := []string{}
+		for _, detectorName := range ignore.ignoredDetectors {
+			ignoredDetectors = append(ignoredDetectors, strings.TrimSpace(detectorName))
+		}
+		configs = append(configs, FileIgnoreConfig{FileName: ignore.pattern, Checksum: ContentChecksum(matching), IgnoreDetectors: ignoredDetectors})
+	}
+	return configs, unmatched
+}
+
+//ReportLegacyIgnoreFile warns when the repository still has a .talismanignore file, but no .talismanrc that replaces it
+func ReportLegacyIgnoreFile(fileExists func(string) bool, result *DetectionResults) {
+	if !fileExists(LegacyIgnoreFileName) || fileExists(DefaultRCFileName) {
+		return
+	}
+	result.Warn(gitrepo.FilePath(LegacyIgnoreFileName), "ignores", fmt.Sprintf("%s is no longer read, so the files it ignores are scanned. Convert it to %s with talisman rc migrate", LegacyIgnoreFileName, DefaultRCFileName), []string{})
+}
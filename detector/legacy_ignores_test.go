@@ -0,0 +1,34 @@
+package detector
+
+import (
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldConvertLegacyIgnoresIntoFileIgnoreConfigs(t *testing.T) {
+	ignores := ReadLegacyIgnores([]byte("# legacy ignores\nprivate.pem\nfixtures/ # ignore:filecontent,filesize\ndeleted.key\n"))
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("private.pem", []byte("key")),
+		gitrepo.NewAddition("fixtures/user.json", []byte("{}")),
+	}
+
+	configs, unmatched := ignores.FileIgnoreConfigs(additions)
+
+	assert.Equal(t, []FileIgnoreConfig{
+		{FileName: "private.pem", Checksum: ContentChecksum(additions[:1]), IgnoreDetectors: []string{}},
+		{FileName: "fixtures/", Checksum: ContentChecksum(additions[1:]), IgnoreDetectors: []string{"filecontent", "filesize"}},
+	}, configs)
+	assert.Equal(t, []string{"deleted.key"}, unmatched)
+}
+
+func TestShouldWarnAboutALegacyIgnoreFileWithoutATalismanRC(t *testing.T) {
+	results := NewDetectionResults()
+	ReportLegacyIgnoreFile(func(fileName string) bool { return fileName == LegacyIgnoreFileName }, results)
+	assert.True(t, results.HasWarnings(), "Expected a .talismanignore without a .talismanrc to be warned about")
+
+	results = NewDetectionResults()
+	ReportLegacyIgnoreFile(func(fileName string) bool { return true }, results)
+	assert.False(t, results.HasWarnings(), "Expected a .talismanignore along with a .talismanrc not to be warned about")
+}
@@ -1,18 +1,37 @@
 package detector
 
-import "regexp"
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"talisman/gitrepo"
+	"unicode/utf8"
+)
 
+//PatternMatcher matches content against a set of regular expressions.
+//Every regular expression is indexed by the literal keywords that any text it matches must contain,
+//so that a single pass over the content decides which of them need to run at all.
 type PatternMatcher struct {
-	regexes []*regexp.Regexp
+	regexes  []*regexp.Regexp
+	keywords *ahoCorasick
+	//keywordRegexes maps each keyword to the regexes that require it
+	keywordRegexes [][]int
+	//alwaysRun lists the regexes that no keyword could be found for
+	alwaysRun []int
+}
+
+//PatternMatch is a single occurrence of a pattern in the content, located by its byte offsets
+type PatternMatch struct {
+	Pattern *regexp.Regexp
+	Text    string
+	Start   int
+	End     int
 }
 
 func (detector PatternMatcher) check(content string) []string {
 	var detected []string
-	for _, regex := range detector.regexes {
-		matches := regex.FindStringSubmatch(content)
-		if matches != nil {
-			detected = append(detected, matches[1:]...)
-		}
+	for _, match := range detector.findAll(content) {
+		detected = append(detected, match.Text)
 	}
 	if detected != nil {
 		return detected
@@ -20,6 +39,158 @@ func (detector PatternMatcher) check(content string) []string {
 	return []string{""}
 }
 
+//findAll returns every match of every pattern in the content, ordered by pattern and then by offset.
+//The text of a match is its first capturing group, or the whole match for patterns without groups.
+func (detector PatternMatcher) findAll(content string) []PatternMatch {
+	var matches []PatternMatch
+	for _, index := range detector.candidates(content) {
+		regex := detector.regexes[index]
+		for _, location := range regex.FindAllStringSubmatchIndex(content, -1) {
+			start, end := location[0], location[1]
+			if len(location) > 2 && location[2] >= 0 {
+				start, end = location[2], location[3]
+			}
+			matches = append(matches, PatternMatch{Pattern: regex, Text: content[start:end], Start: start, End: end})
+		}
+	}
+	return matches
+}
+
+//addedMatches keeps the matches that overlap the lines the addition adds, so that content which is already committed is not reported again.
+//A match that spans several lines, like a private key, is kept if any of its lines is added.
+func addedMatches(addition gitrepo.Addition, content string, matches []PatternMatch) []PatternMatch {
+	if addition.AddedLines == nil {
+		return matches
+	}
+	var result []PatternMatch
+	for _, match := range matches {
+		last := match.End - 1
+		if last < match.Start {
+			last = match.Start
+		}
+		if addition.Adds(lineOfOffset(content, match.Start), lineOfOffset(content, last)) {
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+//lineOfOffset returns the number of the line of the content that the offset is on, counting from 1
+func lineOfOffset(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+//candidates returns the indexes of the regexes whose keywords occur in the content, in the order the regexes were supplied
+func (detector PatternMatcher) candidates(content string) []int {
+	selected := make([]bool, len(detector.regexes))
+	for _, index := range detector.alwaysRun {
+		selected[index] = true
+	}
+	detector.keywords.findAll(asciiLower(content), func(keyword int, start int) {
+		for _, index := range detector.keywordRegexes[keyword] {
+			selected[index] = true
+		}
+	})
+	var result []int
+	for index, isSelected := range selected {
+		if isSelected {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
 func NewSecretsPatternDetector(patterns []*regexp.Regexp) *PatternMatcher {
-	return &PatternMatcher{patterns}
+	matcher := &PatternMatcher{regexes: patterns}
+	keywordIndexes := map[string]int{}
+	var keywords []string
+	for index, pattern := range patterns {
+		required := requiredKeywords(pattern)
+		if required == nil {
+			matcher.alwaysRun = append(matcher.alwaysRun, index)
+			continue
+		}
+		for _, keyword := range required {
+			keywordIndex, ok := keywordIndexes[keyword]
+			if !ok {
+				keywordIndex = len(keywords)
+				keywordIndexes[keyword] = keywordIndex
+				keywords = append(keywords, keyword)
+				matcher.keywordRegexes = append(matcher.keywordRegexes, nil)
+			}
+			matcher.keywordRegexes[keywordIndex] = append(matcher.keywordRegexes[keywordIndex], index)
+		}
+	}
+	matcher.keywords = newAhoCorasick(keywords)
+	return matcher
+}
+
+//requiredKeywords returns lower cased literals, at least one of which occurs in every text the pattern matches.
+//It returns nil when no such literals can be derived from the pattern.
+func requiredKeywords(pattern *regexp.Regexp) []string {
+	parsed, err := syntax.Parse(pattern.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return requiredLiterals(parsed.Simplify())
+}
+
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		literal := asciiLower(string(re.Rune))
+		if literal == "" || utf8.RuneCountInString(literal) != len(literal) {
+			return nil
+		}
+		return []string{literal}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var best []string
+		for _, sub := range re.Sub {
+			if literals := requiredLiterals(sub); literals != nil && shortest(literals) > shortest(best) {
+				best = literals
+			}
+		}
+		return best
+	case syntax.OpAlternate:
+		var union []string
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals == nil {
+				return nil
+			}
+			union = append(union, literals...)
+		}
+		return union
+	}
+	return nil
+}
+
+func shortest(literals []string) int {
+	if literals == nil {
+		return 0
+	}
+	length := len(literals[0])
+	for _, literal := range literals[1:] {
+		if len(literal) < length {
+			length = len(literal)
+		}
+	}
+	return length
+}
+
+//asciiLower lower cases ASCII letters only, so that byte offsets into the result are also valid for the original
+func asciiLower(text string) string {
+	lower := []byte(text)
+	for i, b := range lower {
+		if 'A' <= b && b <= 'Z' {
+			lower[i] = b + ('a' - 'A')
+		}
+	}
+	return string(lower)
 }
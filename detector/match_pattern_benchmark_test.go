@@ -0,0 +1,83 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//patternBenchmarkCorpus is a fixed corpus of source and configuration lines, with a few secrets sprinkled in,
+//so that the numbers reported by the benchmarks below stay comparable across changes.
+var patternBenchmarkCorpus = buildPatternBenchmarkCorpus(2000)
+
+var patternBenchmarkCleanCorpus = buildPatternBenchmarkCorpus(0)
+
+func buildPatternBenchmarkCorpus(secretEvery int) string {
+	lines := []string{
+		"package main",
+		"import \"fmt\"",
+		"func main() { fmt.Println(\"Hello, World\") }",
+		"server:",
+		"  port: 8080",
+		"  host: localhost",
+		"logging.level.root=INFO",
+		"<dependency><groupId>org.example</groupId><artifactId>library</artifactId></dependency>",
+		"const answer = computeTheAnswerToLifeTheUniverseAndEverything(42);",
+		"# A comment describing what the next block of configuration does",
+	}
+	secrets := []string{
+		"\"password\" : UnsafePassword",
+		"<ConsumerSecret>alksjdhfkjaklsdhflk12345adskjf</ConsumerSecret>",
+		"AWS secret key = wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+	}
+	builder := strings.Builder{}
+	for i := 0; i < 20000; i++ {
+		if secretEvery > 0 && i%secretEvery == 0 {
+			builder.WriteString(secrets[(i/secretEvery)%len(secrets)])
+		} else {
+			builder.WriteString(lines[i%len(lines)])
+		}
+		builder.WriteString(fmt.Sprintf(" // %d\n", i))
+	}
+	return builder.String()
+}
+
+//naiveFindAll runs every pattern over the whole content, as the matcher did before keyword prefiltering
+func naiveFindAll(matcher *PatternMatcher, content string) int {
+	count := 0
+	for _, regex := range matcher.regexes {
+		count += len(regex.FindAllStringSubmatchIndex(content, -1))
+	}
+	return count
+}
+
+func BenchmarkPatternMatcher(b *testing.B) {
+	matcher := NewSecretsPatternDetector(detectorPatterns)
+	b.SetBytes(int64(len(patternBenchmarkCorpus)))
+	for i := 0; i < b.N; i++ {
+		matcher.findAll(patternBenchmarkCorpus)
+	}
+}
+
+func BenchmarkPatternMatcherCleanCorpus(b *testing.B) {
+	matcher := NewSecretsPatternDetector(detectorPatterns)
+	b.SetBytes(int64(len(patternBenchmarkCleanCorpus)))
+	for i := 0; i < b.N; i++ {
+		matcher.findAll(patternBenchmarkCleanCorpus)
+	}
+}
+
+func BenchmarkPatternMatcherWithoutPrefilter(b *testing.B) {
+	matcher := NewSecretsPatternDetector(detectorPatterns)
+	b.SetBytes(int64(len(patternBenchmarkCorpus)))
+	for i := 0; i < b.N; i++ {
+		naiveFindAll(matcher, patternBenchmarkCorpus)
+	}
+}
+
+func TestPrefilteredMatcherFindsTheSameMatchesAsRunningEveryPattern(t *testing.T) {
+	matcher := NewSecretsPatternDetector(detectorPatterns)
+	if count := len(matcher.findAll(patternBenchmarkCorpus)); count != naiveFindAll(matcher, patternBenchmarkCorpus) || count == 0 {
+		t.Errorf("Expected the prefiltered matcher to find the same %d matches as running every pattern, found %d", naiveFindAll(matcher, patternBenchmarkCorpus), count)
+	}
+}
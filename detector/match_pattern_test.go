@@ -19,3 +19,35 @@ func TestShouldReturnStringWhenMatchedPasswordPattern(t *testing.T) {
 	assert.Equal(t, []string{"password\" :  123456789"}, NewSecretsPatternDetector([]*regexp.Regexp{testRegexpPassword}).check("password\" :  123456789"))
 	assert.Equal(t, []string{"pw\"  :  123456789"}, NewSecretsPatternDetector([]*regexp.Regexp{testRegexpPw}).check("pw\"  :  123456789"))
 }
+
+func TestShouldReturnEveryOccurrenceWithItsOffsets(t *testing.T) {
+	content := "password: 123456789\nother line\npassword: abcdefghi"
+
+	matches := NewSecretsPatternDetector([]*regexp.Regexp{regexp.MustCompile(`(?i)(password *[:=][^,;\n]{8,})`)}).findAll(content)
+
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, "password: 123456789", matches[0].Text)
+		assert.Equal(t, 0, matches[0].Start)
+		assert.Equal(t, 19, matches[0].End)
+		assert.Equal(t, "password: abcdefghi", matches[1].Text)
+		assert.Equal(t, "password: abcdefghi", content[matches[1].Start:matches[1].End])
+	}
+}
+
+func TestShouldDeriveRequiredKeywordsFromPatterns(t *testing.T) {
+	assert.Equal(t, []string{"password"}, requiredKeywords(testRegexpPassword))
+	assert.Equal(t, []string{"begin rsa private key"}, requiredKeywords(regexp.MustCompile(`(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)`)))
+	assert.Equal(t, []string{"</consumerkey>"}, requiredKeywords(regexp.MustCompile(`(?i)(<ConsumerKey>\S*</ConsumerKey>)`)))
+	assert.Equal(t, []string{"token", "secret"}, requiredKeywords(regexp.MustCompile(`(token|secret)=\w+`)))
+	assert.Nil(t, requiredKeywords(regexp.MustCompile(`[0-9a-f]{32}`)))
+	assert.Nil(t, requiredKeywords(regexp.MustCompile(`(token|[0-9]+)\w+`)))
+}
+
+func TestShouldOnlyRunPatternsWhoseKeywordsOccurInContent(t *testing.T) {
+	matcher := NewSecretsPatternDetector([]*regexp.Regexp{testRegexpPassword, testRegexpPw, regexp.MustCompile(`[0-9a-f]{32}`)})
+
+	assert.Equal(t, []int{2}, matcher.candidates("nothing to see here"))
+	assert.Equal(t, []int{0, 1, 2}, matcher.candidates("PassWord = 123, pw = 456"))
+	assert.Equal(t, []int{0, 2}, matcher.candidates("PassWord = 123"))
+	assert.Equal(t, []int{1, 2}, matcher.candidates("pw = 123"))
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"regexp"
-	"sync"
+	"strings"
 	"talisman/gitrepo"
 )
 
 type PatternDetector struct {
+	patterns       []*regexp.Regexp
 	secretsPattern *PatternMatcher
+	jobs           int
 }
 
 var (
@@ -35,32 +37,36 @@ type match struct {
 	name       gitrepo.FileName
 	path       gitrepo.FilePath
 	commits    []string
-	detections []string
+	detections []PatternMatch
+	err        error
 }
 
 //Test tests the contents of the Additions to ensure that they don't look suspicious
 func (detector PatternDetector) Test(additions []gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
 	cc := NewChecksumCompare(additions, ignoreConfig)
+	placeholders := NewPlaceholderClassifier(ignoreConfig.PlaceholderConfig)
+	secretsPattern := detector.withCustomPatterns(ignoreConfig.CustomPatterns)
 	matches := make(chan match, 512)
-	ignoredFilePaths := make(chan gitrepo.FilePath, 512)
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(len(additions))
-	for _, addition := range additions {
-		go func(addition gitrepo.Addition) {
-			defer waitGroup.Done()
-			if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition) {
-				ignoredFilePaths <- addition.Path
-				return
-			}
-			detections := detector.secretsPattern.check(string(addition.Data))
-			matches <- match{name: addition.Name, path: addition.Path, detections: detections, commits: addition.Commits}
-		}(addition)
-	}
-	go func() {
-		waitGroup.Wait()
+	ignoredAdditions := make(chan gitrepo.Addition, 512)
+	forEachAddition(additions, detector.jobs, func(addition gitrepo.Addition) error {
+		if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition, "filecontent") {
+			ignoredAdditions <- addition
+			return nil
+		}
+		data, err := addition.Content()
+		if err != nil {
+			return err
+		}
+		detections := addedMatches(addition, string(data), secretsPattern.findAll(string(data)))
+		detections = append(detections, joinedLiteralMatches(addition, string(data), secretsPattern)...)
+		matches <- match{name: addition.Name, path: addition.Path, detections: detections, commits: addition.Commits}
+		return nil
+	}, func(addition gitrepo.Addition, err error) {
+		matches <- match{name: addition.Name, path: addition.Path, commits: addition.Commits, err: err}
+	}, func() {
 		close(matches)
-		close(ignoredFilePaths)
-	}()
+		close(ignoredAdditions)
+	})
 	for ignoredChanHasMore, matchChanHasMore := true, true; ignoredChanHasMore || matchChanHasMore; {
 		select {
 		case match, hasMore := <-matches:
@@ -68,28 +74,48 @@ func (detector PatternDetector) Test(additions []gitrepo.Addition, ignoreConfig
 				matchChanHasMore = false
 				continue
 			}
-			detector.processMatch(match, result)
-		case ignore, hasMore := <-ignoredFilePaths:
+			if match.err != nil {
+				ignoreConfig.DetectorErrorPolicy.report(match.path, match.commits, "pattern", match.err, result)
+				continue
+			}
+			detector.processMatch(match, placeholders, result)
+		case ignore, hasMore := <-ignoredAdditions:
 			if !hasMore {
 				ignoredChanHasMore = false
 				continue
 			}
-			detector.processIgnore(ignore, result)
+			detector.processIgnore(ignore, ignoreConfig, result)
 		}
 	}
 }
 
-func (detector PatternDetector) processIgnore(ignoredFilePath gitrepo.FilePath, result *DetectionResults) {
+//joinedLiteralMatches returns the matches in the added tokens that only exist once concatenated literals are joined.
+//Tokens that occur as they are in the content were already matched along with the rest of it.
+func joinedLiteralMatches(addition gitrepo.Addition, content string, secretsPattern *PatternMatcher) []PatternMatch {
+	var matches []PatternMatch
+	for _, token := range addedTokens(addition, tokenizerFor(addition.Name).tokenize(content)) {
+		if !strings.Contains(content, token.text) {
+			matches = append(matches, secretsPattern.findAll(token.text)...)
+		}
+	}
+	return matches
+}
+
+func (detector PatternDetector) processIgnore(ignoredAddition gitrepo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
+	source := ignoreConfig.SourceOf(ignoredAddition)
 	log.WithFields(log.Fields{
-		"filePath": ignoredFilePath,
+		"filePath": ignoredAddition.Path,
+		"source":   source,
 	}).Info("Ignoring addition as it was specified to be ignored.")
-	result.Ignore(ignoredFilePath, "filecontent")
+	result.IgnoreFrom(ignoredAddition.Path, "filecontent", source, ignoreConfig.JustificationOf(ignoredAddition))
 }
 
-func (detector PatternDetector) processMatch(match match, result *DetectionResults) {
-	for _, detection := range match.detections {
-		if detection != "" {
-			if string(match.name) == DefaultRCFileName {
+func (detector PatternDetector) processMatch(match match, placeholders *PlaceholderClassifier, result *DetectionResults) {
+	for _, patternMatch := range match.detections {
+		if detection := patternMatch.Text; detection != "" {
+			if isPlaceholder, reason := placeholders.Classify(detection); isPlaceholder {
+				placeholders.report(match.path, detection, reason, match.commits, result)
+			} else if string(match.name) == DefaultRCFileName {
 				log.WithFields(log.Fields{
 					"filePath": match.path,
 					"pattern":  detection,
@@ -100,13 +126,40 @@ func (detector PatternDetector) processMatch(match match, result *DetectionResul
 					"filePath": match.path,
 					"pattern":  detection,
 				}).Info("Failing file as it matched pattern.")
-				result.Fail(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits)
+				result.FailWithValue(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), detection, match.commits)
 			}
 		}
 	}
 }
 
+//withCustomPatterns returns a matcher for the pre-configured patterns along with the supplied custom ones.
+//Custom patterns that are not valid regular expressions are skipped, as they are reported by talisman rc validate.
+func (detector PatternDetector) withCustomPatterns(customPatterns []string) *PatternMatcher {
+	if len(customPatterns) == 0 {
+		return detector.secretsPattern
+	}
+	patterns := append([]*regexp.Regexp{}, detector.patterns...)
+	for _, customPattern := range customPatterns {
+		pattern, err := regexp.Compile(customPattern)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"pattern": customPattern,
+				"error":   err,
+			}).Error("Skipping custom pattern as it is not a valid regular expression")
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return NewSecretsPatternDetector(patterns)
+}
+
 //NewPatternDetector returns a PatternDetector that tests Additions against the pre-configured patterns
 func NewPatternDetector() *PatternDetector {
-	return &PatternDetector{NewSecretsPatternDetector(detectorPatterns)}
+	return &PatternDetector{patterns: detectorPatterns, secretsPattern: NewSecretsPatternDetector(detectorPatterns)}
+}
+
+//WithJobs sets the number of additions that are examined concurrently
+func (detector *PatternDetector) WithJobs(jobs int) *PatternDetector {
+	detector.jobs = jobs
+	return detector
 }
@@ -26,13 +26,38 @@ func TestShouldIgnorePasswordPatterns(t *testing.T) {
 	content := []byte("\"password\" : UnsafePassword")
 	filename := "secret.txt"
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, content)}
-	fileIgnoreConfig := FileIgnoreConfig{filename, "833b6c24c8c2c5c7e1663226dc401b29c005492dc76a1150fc0e0f07f29d4cc3", []string{"filecontent"}}
+	fileIgnoreConfig := FileIgnoreConfig{FileName: filename, Checksum: "4bc3a6de30fa1a70499e47d44711e8e4f99255b6fa52b920462351b57ee7222f", IgnoreDetectors: []string{"filecontent"}}
 	ignores := TalismanRCIgnore{FileIgnoreConfig:[]FileIgnoreConfig{fileIgnoreConfig}}
 
 	NewPatternDetector().Test(additions, ignores, results)
 	assert.True(t, results.Successful(), "Expected file %s to be ignored by pattern", filename)
 }
 
+func TestShouldOnlyReportPatternsThatOverlapAddedLines(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("password=alreadyCommitted\nBEGIN RSA PRIVATE KEY\naghjdjadslgjagsfjlsgjalsgjaghjldasja\nEND RSA PRIVATE KEY\n")
+	addition := gitrepo.NewAddition("secret.txt", content)
+	addition.AddedLines = []gitrepo.LineRange{{First: 3, Last: 3}}
+
+	NewPatternDetector().Test([]gitrepo.Addition{addition}, TalismanRCIgnore{}, results)
+
+	failures := results.GetFailures(addition.Path)
+	if assert.Len(t, failures, 1, "Expected only the key that a line was added to to be reported") {
+		assert.Contains(t, failures[0].Message, "BEGIN RSA PRIVATE KEY")
+	}
+}
+
+func TestShouldDetectPatternsSplitAcrossConcatenatedLiterals(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("const key = \"<ConsumerKey>alksjdhfkjakl\" +\n  \"sdhflk12345adskjf</ConsumerKey>\";")
+	additions := []gitrepo.Addition{gitrepo.NewAddition("config.js", content)}
+
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.Equal(t, "Potential secret pattern : <ConsumerKey>alksjdhfkjaklsdhflk12345adskjf</ConsumerKey>", getFailureMessage(results, additions))
+	assert.Len(t, results.GetFailures(additions[0].Path), 1)
+}
+
 func shouldPassDetectionOfSecretPattern(filename string, content []byte, t *testing.T) {
 	results := NewDetectionResults()
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, content)}
@@ -0,0 +1,143 @@
+package detector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"talisman/gitrepo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	//PlaceholderActionIgnore drops matches whose value is a placeholder
+	PlaceholderActionIgnore string = "ignore"
+	//PlaceholderActionWarn reports matches whose value is a placeholder as warnings instead of failures
+	PlaceholderActionWarn string = "warn"
+)
+
+var (
+	placeholderPatterns = []struct {
+		reason  string
+		pattern *regexp.Regexp
+	}{
+		{"shell/env interpolation", regexp.MustCompile(`^\$(\{[A-Za-z_]\w*(:?\?[^}]*)?\}|[A-Za-z_]\w*|\([^)]+\))$`)},
+		{"Spring property placeholder", regexp.MustCompile(`^\$\{[A-Za-z_][\w.-]*\}$`)},
+		{"windows env interpolation", regexp.MustCompile(`^%[A-Za-z_]\w*%$`)},
+		{"Helm/Go template expression", regexp.MustCompile(`^\{\{-?\s*(\.|\$|(include|template|required|default|env)\b).*\}\}$`)},
+		{"Ansible/Jinja variable", regexp.MustCompile(`^\{\{\s*[A-Za-z_][\w.\[\]'"]*\s*(\|.*)?\}\}$`)},
+		{"Jinja template expression", regexp.MustCompile(`^\{[{%].*[%}]\}$`)},
+		{"Ansible vault reference", regexp.MustCompile(`^!vault\b`)},
+		{"python format placeholder", regexp.MustCompile(`^%\([\w.]+\)[sdr]$`)},
+		{"angle bracket placeholder", regexp.MustCompile(`^<[\w .-]+>$`)},
+		{"masked value", regexp.MustCompile(`^(?i)(x+|\*+|\.{3,}|#+)$`)},
+	}
+
+	//defaultedPatterns match interpolations and template expressions that fall back to a default value,
+	//which is classified on its own, as it is used whenever the variable is not set
+	defaultedPatterns = []struct {
+		reason  string
+		pattern *regexp.Regexp
+	}{
+		{"shell/env interpolation", regexp.MustCompile(`^\$\{[A-Za-z_]\w*:?[-=+](.*)\}$`)},
+		{"Spring property placeholder", regexp.MustCompile(`^\$\{[A-Za-z_][\w.-]*:([^-=+?}].*)?\}$`)},
+		{"Helm/Go template expression", regexp.MustCompile(`^\{\{.*\bdefault\s*\(?\s*(?:"([^"]*)"|'([^']*)'|` + "`([^`]*)`" + `).*\}\}$`)},
+	}
+
+	defaultDummyValues = []string{
+		"changeme", "change_me", "change-me", "changeit", "passw0rd", "example",
+		"dummy", "placeholder", "redacted", "notasecret", "not-a-secret", "todo", "tbd",
+	}
+
+	keyValueSeparator = regexp.MustCompile(`[:=]`)
+	xmlElementValue   = regexp.MustCompile(`^<[^>]+>(.*)</[^>]+>$`)
+)
+
+//PlaceholderConfig configures how values that only stand in for a secret are treated by the content detectors
+type PlaceholderConfig struct {
+	DummyValues []string `yaml:"dummy_values,omitempty"`
+	Action      string   `yaml:"action,omitempty"`
+}
+
+//PlaceholderClassifier recognises values that are placeholders for a secret rather than the secret itself,
+//such as environment interpolations, template expressions and well known dummy values.
+//Content detectors consult it before failing a file.
+type PlaceholderClassifier struct {
+	dummyValues map[string]bool
+	action      string
+}
+
+//NewPlaceholderClassifier returns a PlaceholderClassifier that knows the built in dummy values along with the configured ones
+func NewPlaceholderClassifier(config PlaceholderConfig) *PlaceholderClassifier {
+	dummyValues := map[string]bool{}
+	for _, value := range append(defaultDummyValues, config.DummyValues...) {
+		dummyValues[strings.ToLower(value)] = true
+	}
+	action := PlaceholderActionIgnore
+	if config.Action == PlaceholderActionWarn {
+		action = PlaceholderActionWarn
+	}
+	return &PlaceholderClassifier{dummyValues: dummyValues, action: action}
+}
+
+//Classify answers whether the value in the supplied detection is a placeholder, along with the reason for it being one.
+//A detection may either be a bare value or a key value pair such as "password: ${DB_PASSWORD}".
+func (pc *PlaceholderClassifier) Classify(detection string) (bool, string) {
+	if pc.dummyValues[strings.ToLower(strings.TrimSpace(detection))] {
+		return true, "dummy value"
+	}
+	value := placeholderValue(detection)
+	if value == "" {
+		return false, ""
+	}
+	return pc.classifyValue(value)
+}
+
+//classifyValue classifies a value that has been taken out of its detection.
+//An interpolation with a default value is only a placeholder if its default value is empty or a placeholder itself.
+func (pc *PlaceholderClassifier) classifyValue(value string) (bool, string) {
+	for _, defaulted := range defaultedPatterns {
+		if match := defaulted.pattern.FindStringSubmatch(value); match != nil {
+			fallback := strings.TrimSpace(strings.Join(match[1:], ""))
+			if fallback == "" {
+				return true, defaulted.reason
+			}
+			return pc.classifyValue(fallback)
+		}
+	}
+	for _, placeholder := range placeholderPatterns {
+		if placeholder.pattern.MatchString(value) {
+			return true, placeholder.reason
+		}
+	}
+	if pc.dummyValues[strings.ToLower(value)] {
+		return true, "dummy value"
+	}
+	return false, ""
+}
+
+//report records a detection whose value is a placeholder according to the configured action.
+func (pc *PlaceholderClassifier) report(path gitrepo.FilePath, detection string, reason string, commits []string, result *DetectionResults) {
+	log.WithFields(log.Fields{
+		"filePath": path,
+		"value":    detection,
+		"reason":   reason,
+		"action":   pc.action,
+	}).Info("Not failing file as the detected value is a placeholder.")
+	if pc.action == PlaceholderActionWarn {
+		result.Warn(path, "filecontent", fmt.Sprintf("Placeholder value (%s) : %s", reason, detection), commits)
+	}
+}
+
+func placeholderValue(detection string) string {
+	value := strings.TrimSpace(detection)
+	if match := xmlElementValue.FindStringSubmatch(value); match != nil {
+		value = match[1]
+	} else if location := keyValueSeparator.FindStringIndex(value); location != nil && !strings.HasPrefix(value, "$") && !strings.HasPrefix(value, "{") {
+		value = value[location[1]:]
+	}
+	value = strings.TrimSpace(value)
+	value = strings.TrimRight(value, ",;")
+	return strings.TrimSpace(strings.Trim(value, `"'`+"`"))
+}
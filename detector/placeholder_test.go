@@ -0,0 +1,83 @@
+package detector
+
+import (
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldClassifyInterpolationsAndTemplatesAsPlaceholders(t *testing.T) {
+	classifier := NewPlaceholderClassifier(PlaceholderConfig{})
+
+	assertPlaceholder(t, classifier, "password: ${DB_PASSWORD}", "shell/env interpolation")
+	assertPlaceholder(t, classifier, "password=$DB_PASSWORD", "shell/env interpolation")
+	assertPlaceholder(t, classifier, "password: ${DB_PASSWORD:?is required}", "shell/env interpolation")
+	assertPlaceholder(t, classifier, "password: ${DB_PASSWORD:-}", "shell/env interpolation")
+	assertPlaceholder(t, classifier, "password: ${DB_PASSWORD:-changeme}", "dummy value")
+	assertPlaceholder(t, classifier, "password: ${DB_PASSWORD:-${FALLBACK_PASSWORD}}", "shell/env interpolation")
+	assertPlaceholder(t, classifier, "spring.datasource.password=${db.password}", "Spring property placeholder")
+	assertPlaceholder(t, classifier, "password = \"{{ .Values.pw }}\"", "Helm/Go template expression")
+	assertPlaceholder(t, classifier, "password: \"{{ vault_db_password }}\"", "Ansible/Jinja variable")
+	assertPlaceholder(t, classifier, "pwd: %(PASS)s", "python format placeholder")
+	assertPlaceholder(t, classifier, "<password><your-password></password>", "angle bracket placeholder")
+}
+
+func TestShouldClassifyDummyValuesAsPlaceholders(t *testing.T) {
+	classifier := NewPlaceholderClassifier(PlaceholderConfig{DummyValues: []string{"hunter2hunter2", "c2VjcmV0IHZhbHVlIQ=="}})
+
+	assertPlaceholder(t, classifier, "password: changeme", "dummy value")
+	assertPlaceholder(t, classifier, "\"password\" : \"ChangeMe\"", "dummy value")
+	assertPlaceholder(t, classifier, "password: hunter2hunter2", "dummy value")
+	assertPlaceholder(t, classifier, "c2VjcmV0IHZhbHVlIQ==", "dummy value")
+}
+
+func TestShouldClassifyTheDefaultValuesOfInterpolationsOnTheirOwn(t *testing.T) {
+	classifier := NewPlaceholderClassifier(PlaceholderConfig{})
+
+	for _, detection := range []string{"password: ${DB_PASSWORD:-Xk9$mQ2vLp7Rz}", "db.pass=${DB_PASS:Xk9mQ2vLp7RzTq}", "password: {{ default \"Xk9mQ2vLp7RzTq\" .Values.pw }}", "password: {{ .Values.pw | default \"Xk9mQ2vLp7RzTq\" }}"} {
+		isPlaceholder, _ := classifier.Classify(detection)
+		assert.False(t, isPlaceholder, "Expected the default value of %s to be classified as a real value", detection)
+	}
+	assertPlaceholder(t, classifier, "db.pass=${DB_PASS:changeme}", "dummy value")
+	assertPlaceholder(t, classifier, "password: {{ default .Values.fallback .Values.pw }}", "Helm/Go template expression")
+}
+
+func TestShouldNotClassifyRealValuesAsPlaceholders(t *testing.T) {
+	classifier := NewPlaceholderClassifier(PlaceholderConfig{})
+
+	for _, detection := range []string{"\"password\" : UnsafePassword", "PWD=appropriate", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "password: $ecret$auce", "password: secret", "password: null"} {
+		isPlaceholder, _ := classifier.Classify(detection)
+		assert.False(t, isPlaceholder, "Expected %s to not be classified as a placeholder", detection)
+	}
+}
+
+func TestPatternDetectorShouldNotFailOnPlaceholders(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("password: ${DB_PASSWORD}\npassword = \"{{ .Values.pw }}\"\npwd: %(PASS)s\npassword: changeme\n")
+	additions := []gitrepo.Addition{gitrepo.NewAddition("config.yml", content)}
+
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.False(t, results.HasFailures(), "Expected placeholders to not fail the file")
+	assert.False(t, results.HasWarnings(), "Expected placeholders to be ignored by default")
+}
+
+func TestPatternDetectorShouldDowngradePlaceholdersToWarningsWhenConfigured(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("password: ${DB_PASSWORD}")
+	additions := []gitrepo.Addition{gitrepo.NewAddition("config.yml", content)}
+	ignores := TalismanRCIgnore{PlaceholderConfig: PlaceholderConfig{Action: PlaceholderActionWarn}}
+
+	NewPatternDetector().Test(additions, ignores, results)
+
+	assert.False(t, results.HasFailures(), "Expected placeholders to not fail the file")
+	assert.True(t, results.HasWarnings(), "Expected placeholders to be reported as warnings")
+	assert.Equal(t, "Placeholder value (shell/env interpolation) : password: ${DB_PASSWORD}", results.Results[0].WarningList[0].Message)
+}
+
+func assertPlaceholder(t *testing.T, classifier *PlaceholderClassifier, detection string, expectedReason string) {
+	isPlaceholder, reason := classifier.Classify(detection)
+	assert.True(t, isPlaceholder, "Expected %s to be classified as a placeholder", detection)
+	assert.Equal(t, expectedReason, reason, "Unexpected reason for %s", detection)
+}
@@ -0,0 +1,258 @@
+package detector
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	fileIgnoreSectionPattern = regexp.MustCompile(`^fileignoreconfig\s*:\s*(#.*)?$`)
+	emptyFileIgnoreSection   = regexp.MustCompile(`^fileignoreconfig\s*:\s*(\[\s*\])?\s*(#.*)?$`)
+	checksumLinePattern      = regexp.MustCompile(`^(\s*(?:-\s*)?checksum\s*:\s*)(['"]?)[0-9A-Za-z]*(['"]?)(.*)$`)
+	placeholderSection       = regexp.MustCompile(`^placeholderconfig\s*:\s*(\{\s*\})?\s*(#.*)?$`)
+	dummyValuesKeyPattern    = regexp.MustCompile(`^(\s+)dummy_values\s*:\s*(\[\s*\])?\s*(#.*)?$`)
+)
+
+//fileIgnoreEntry is the range of lines of a fileignoreconfig entry, including the comments right above it
+type fileIgnoreEntry struct {
+	start, first, end int
+}
+
+//RemoveFileIgnores returns the contents of a .talismanrc file without the fileignoreconfig entries at the supplied indexes.
+//Everything else, including comments and the order of the remaining entries, is kept as it is.
+func RemoveFileIgnores(fileContents []byte, indexes []int) ([]byte, error) {
+	lines, entries, err := fileIgnoreEntries(fileContents)
+	if err != nil {
+		return nil, err
+	}
+	remove := map[int]bool{}
+	for _, index := range indexes {
+		remove[index] = true
+	}
+	var result []string
+	next := 0
+	for i, entry := range entries {
+		if remove[i] {
+			result = append(result, lines[next:entry.start]...)
+			next = entry.end
+		}
+	}
+	return []byte(strings.Join(append(result, lines[next:]...), "")), nil
+}
+
+//UpdateFileIgnoreChecksums returns the contents of a .talismanrc file with the checksums of the fileignoreconfig entries at the indexes replaced.
+//An entry without a checksum gets one. Everything else, including comments and the order of the entries, is kept as it is.
+func UpdateFileIgnoreChecksums(fileContents []byte, checksums map[int]string) ([]byte, error) {
+	lines, entries, err := fileIgnoreEntries(fileContents)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	next := 0
+	for i, entry := range entries {
+		checksum, ok := checksums[i]
+		if !ok {
+			continue
+		}
+		result = append(result, lines[next:entry.first]...)
+		result = append(result, withChecksum(lines[entry.first:entry.end], checksum)...)
+		next = entry.end
+	}
+	return []byte(strings.Join(append(result, lines[next:]...), "")), nil
+}
+
+//AppendFileIgnores returns the contents of a .talismanrc file with the entries added after its fileignoreconfig entries.
+//Everything else, including comments, is kept as it is.
+func AppendFileIgnores(fileContents []byte, ignores []FileIgnoreConfig) ([]byte, error) {
+	lines, entries, err := fileIgnoreEntries(fileContents)
+	if err != nil {
+		return nil, err
+	}
+	marshalled, err := yaml.Marshal(ignores)
+	if err != nil {
+		return nil, err
+	}
+	added := strings.SplitAfter(strings.TrimSuffix(string(marshalled), "\n"), "\n")
+	at := len(lines)
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		content, _ := splitLineEnding(lines[last.first])
+		for i := range added {
+			added[i] = content[:len(content)-len(strings.TrimLeft(content, " "))] + added[i]
+		}
+		at = last.end
+	} else {
+		added = append([]string{"fileignoreconfig:\n"}, added...)
+		for i, line := range lines {
+			if content, _ := splitLineEnding(line); emptyFileIgnoreSection.MatchString(content) {
+				lines = append(append(append([]string{}, lines[:i]...), ""), lines[i+1:]...)
+				at = i
+				break
+			}
+		}
+	}
+	result := append([]string{}, lines[:at]...)
+	if len(result) > 0 && result[len(result)-1] != "" && !strings.HasSuffix(result[len(result)-1], "\n") {
+		result[len(result)-1] += "\n"
+	}
+	result = append(result, added...)
+	result[len(result)-1] += "\n"
+	return []byte(strings.Join(append(result, lines[at:]...), "")), nil
+}
+
+//AppendDummyValues returns the contents of a .talismanrc file with the values added to the dummy values of its placeholderconfig.
+//Everything else, including comments, is kept as it is.
+func AppendDummyValues(fileContents []byte, values []string) ([]byte, error) {
+	if len(values) == 0 {
+		return fileContents, nil
+	}
+	expected := append(append([]string{}, NewTalismanRCIgnore(fileContents).PlaceholderConfig.DummyValues...), values...)
+	var items []string
+	for _, value := range values {
+		marshalled, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, "- "+strings.TrimSuffix(string(marshalled), "\n")+"\n")
+	}
+	lines := strings.SplitAfter(string(fileContents), "\n")
+	section := -1
+	for i, line := range lines {
+		if content, ending := splitLineEnding(line); placeholderSection.MatchString(content) {
+			lines[i] = "placeholderconfig:" + ending
+			section = i
+			break
+		}
+	}
+	var added []string
+	at := len(lines)
+	if section < 0 {
+		added = append([]string{"placeholderconfig:\n", "  dummy_values:\n"}, indented(items, "  ")...)
+	} else {
+		at = section + 1
+		key, keyIndentation, itemIndentation, childIndentation := -1, "", "", "  "
+		for i := section + 1; i < len(lines); i++ {
+			content, ending := splitLineEnding(lines[i])
+			trimmed := strings.TrimSpace(content)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			indentation := content[:len(content)-len(strings.TrimLeft(content, " "))]
+			if indentation == "" || (key >= 0 && len(indentation) < len(keyIndentation)) ||
+				(key >= 0 && indentation == keyIndentation && !strings.HasPrefix(trimmed, "-")) {
+				break
+			}
+			if at == section+1 {
+				childIndentation = indentation
+			}
+			if groups := dummyValuesKeyPattern.FindStringSubmatch(content); groups != nil && key < 0 {
+				key, keyIndentation, itemIndentation = i, groups[1], groups[1]
+				lines[i] = keyIndentation + "dummy_values:" + ending
+			} else if key >= 0 && itemIndentation == keyIndentation && strings.HasPrefix(trimmed, "-") {
+				itemIndentation = indentation
+			}
+			at = i + 1
+		}
+		if key < 0 {
+			keyIndentation, itemIndentation = childIndentation, childIndentation
+			added = append(added, keyIndentation+"dummy_values:\n")
+		}
+		added = append(added, indented(items, itemIndentation)...)
+	}
+	result := append([]string{}, lines[:at]...)
+	if len(result) > 0 && result[len(result)-1] != "" && !strings.HasSuffix(result[len(result)-1], "\n") {
+		result[len(result)-1] += "\n"
+	}
+	rewritten := []byte(strings.Join(append(append(result, added...), lines[at:]...), ""))
+	if actual := NewTalismanRCIgnore(rewritten).PlaceholderConfig.DummyValues; !reflect.DeepEqual(expected, actual) {
+		return nil, fmt.Errorf("could not add the dummy values, as placeholderconfig is not written as a block mapping")
+	}
+	return rewritten, nil
+}
+
+//indented returns the lines with the indentation added to each of them
+func indented(lines []string, indentation string) []string {
+	result := []string{}
+	for _, line := range lines {
+		result = append(result, indentation+line)
+	}
+	return result
+}
+
+//withChecksum returns the lines of an entry with the value of its checksum key replaced, or a checksum key added below its first line
+func withChecksum(lines []string, checksum string) []string {
+	result := append([]string{}, lines...)
+	for i, line := range result {
+		content, ending := splitLineEnding(line)
+		if groups := checksumLinePattern.FindStringSubmatch(content); groups != nil {
+			result[i] = groups[1] + groups[2] + checksum + groups[3] + groups[4] + ending
+			return result
+		}
+	}
+	content, ending := splitLineEnding(result[0])
+	if ending == "" {
+		ending = "\n"
+		result[0] += ending
+	}
+	indentation := len(content) - len(strings.TrimLeft(strings.TrimLeft(content, " "), "- "))
+	checksumLine := strings.Repeat(" ", indentation) + "checksum: " + checksum + ending
+	return append(result[:1], append([]string{checksumLine}, result[1:]...)...)
+}
+
+//fileIgnoreEntries splits the contents of a .talismanrc file into lines, which keep their line endings,
+//and finds the lines of each of the fileignoreconfig entries, in the order they are parsed in.
+//Entries can only be found if the fileignoreconfig is written as a block list, like talisman suggests it.
+func fileIgnoreEntries(fileContents []byte) ([]string, []fileIgnoreEntry, error) {
+	lines := strings.SplitAfter(string(fileContents), "\n")
+	var entries []fileIgnoreEntry
+	section, itemIndentation := -1, -1
+	for i := 0; i < len(lines); i++ {
+		content, _ := splitLineEnding(lines[i])
+		trimmed := strings.TrimSpace(content)
+		if section < 0 {
+			if fileIgnoreSectionPattern.MatchString(content) {
+				section = i
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indentation := len(content) - len(strings.TrimLeft(content, " "))
+		if indentation == 0 && !strings.HasPrefix(trimmed, "-") {
+			break
+		}
+		if strings.HasPrefix(trimmed, "-") && (itemIndentation < 0 || indentation == itemIndentation) {
+			itemIndentation = indentation
+			if len(entries) > 0 {
+				entries[len(entries)-1].end = commentsAbove(lines, i)
+			}
+			entries = append(entries, fileIgnoreEntry{start: commentsAbove(lines, i), first: i})
+		}
+		if len(entries) > 0 {
+			entries[len(entries)-1].end = i + 1
+		}
+	}
+	if expected := len(NewTalismanRCIgnore(fileContents).FileIgnoreConfig); expected != len(entries) {
+		return nil, nil, fmt.Errorf("found %d of the %d fileignoreconfig entries, as fileignoreconfig is not written as a block list", len(entries), expected)
+	}
+	return lines, entries, nil
+}
+
+//commentsAbove returns the index of the first of the comment lines right above the line at the index
+func commentsAbove(lines []string, index int) int {
+	for index > 0 && strings.HasPrefix(strings.TrimSpace(lines[index-1]), "#") {
+		index--
+	}
+	return index
+}
+
+//splitLineEnding returns the content of a line, along with its line ending
+func splitLineEnding(line string) (string, string) {
+	content := strings.TrimRight(line, "\r\n")
+	return content, line[len(content):]
+}
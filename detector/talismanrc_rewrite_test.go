@@ -0,0 +1,99 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const talismanRCWithComments = `# ignores reviewed by the security team
+fileignoreconfig:
+# test fixture
+- filename: private.pem
+  checksum: 1db800b79e6e9695adc451f77be974dc47bcd84d42873560d7767bfca30db8b1
+  ignore_detectors: [filename]
+- filename: "*.lock"
+  ignore_detectors: [filesize] # lock files are big
+
+# deleted long ago
+- filename: old.key
+  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c5
+scopeconfig:
+- scope: go
+`
+
+func TestShouldRemoveFileIgnoresAlongWithTheirComments(t *testing.T) {
+	rewritten, err := RemoveFileIgnores([]byte(talismanRCWithComments), []int{0, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# ignores reviewed by the security team
+fileignoreconfig:
+- filename: "*.lock"
+  ignore_detectors: [filesize] # lock files are big
+
+scopeconfig:
+- scope: go
+`, string(rewritten))
+}
+
+func TestShouldUpdateAndAddChecksumsOfFileIgnores(t *testing.T) {
+	rewritten, err := UpdateFileIgnoreChecksums([]byte(talismanRCWithComments), map[int]string{0: "new-checksum", 1: "lock-checksum"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# ignores reviewed by the security team
+fileignoreconfig:
+# test fixture
+- filename: private.pem
+  checksum: new-checksum
+  ignore_detectors: [filename]
+- filename: "*.lock"
+  checksum: lock-checksum
+  ignore_detectors: [filesize] # lock files are big
+
+# deleted long ago
+- filename: old.key
+  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c5
+scopeconfig:
+- scope: go
+`, string(rewritten))
+}
+
+func TestShouldRefuseToRewriteFileIgnoresThatAreNotABlockList(t *testing.T) {
+	_, err := RemoveFileIgnores([]byte("fileignoreconfig: [{filename: private.pem}]\n"), []int{0})
+
+	assert.Error(t, err)
+}
+
+func TestShouldAppendFileIgnoresAfterTheExistingOnes(t *testing.T) {
+	ignores := []FileIgnoreConfig{{FileName: "fixtures/", Checksum: "abc", IgnoreDetectors: []string{"filecontent"}}}
+
+	rewritten, err := AppendFileIgnores([]byte("fileignoreconfig:\n  # test fixture\n  - filename: private.pem\n    ignore_detectors: [filename]\nscopeconfig:\n  - scope: go\n"), ignores)
+	assert.NoError(t, err)
+	assert.Equal(t, "fileignoreconfig:\n  # test fixture\n  - filename: private.pem\n    ignore_detectors: [filename]\n"+
+		"  - filename: fixtures/\n    checksum: abc\n    ignore_detectors:\n    - filecontent\nscopeconfig:\n  - scope: go\n", string(rewritten))
+
+	rewritten, err = AppendFileIgnores([]byte("fileignoreconfig: []\nscopeconfig: []\n"), ignores)
+	assert.NoError(t, err)
+	assert.Equal(t, "fileignoreconfig:\n- filename: fixtures/\n  checksum: abc\n  ignore_detectors:\n  - filecontent\nscopeconfig: []\n", string(rewritten))
+
+	rewritten, err = AppendFileIgnores([]byte("scopeconfig:\n- scope: go"), ignores)
+	assert.NoError(t, err)
+	assert.Equal(t, "scopeconfig:\n- scope: go\nfileignoreconfig:\n- filename: fixtures/\n  checksum: abc\n  ignore_detectors:\n  - filecontent\n", string(rewritten))
+}
+
+func TestShouldAppendDummyValuesToThePlaceholderConfig(t *testing.T) {
+	rewritten, err := AppendDummyValues([]byte("placeholderconfig:\n  dummy_values:\n    - changeme # the default\n  action: warn\nscopeconfig: []\n"), []string{"hunter2", "yes"})
+	assert.NoError(t, err)
+	assert.Equal(t, "placeholderconfig:\n  dummy_values:\n    - changeme # the default\n    - hunter2\n    - \"yes\"\n  action: warn\nscopeconfig: []\n", string(rewritten))
+
+	rewritten, err = AppendDummyValues([]byte("placeholderconfig: {}\nscopeconfig: []\n"), []string{"hunter2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "placeholderconfig:\n  dummy_values:\n  - hunter2\nscopeconfig: []\n", string(rewritten))
+
+	rewritten, err = AppendDummyValues([]byte("scopeconfig: []"), []string{"hunter2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "scopeconfig: []\nplaceholderconfig:\n  dummy_values:\n  - hunter2\n", string(rewritten))
+
+	_, err = AppendDummyValues([]byte("placeholderconfig: {dummy_values: [changeme]}\n"), []string{"hunter2"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,298 @@
+package detector
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"talisman/gitrepo"
+
+	"gopkg.in/yaml.v2"
+)
+
+//DetectorNames lists the detectors that can be named in ignore_detectors
+var DetectorNames = []string{"filecontent", "filename", "filesize"}
+
+var (
+	yamlErrorLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	checksumPattern      = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	fileNameLinePattern  = regexp.MustCompile(`^\s*-?\s*filename\s*:`)
+	scopeLinePattern     = regexp.MustCompile(`^\s*-?\s*scope\s*:`)
+)
+
+//Severity tells whether a Diagnostic makes a .talismanrc file invalid
+type Severity string
+
+const (
+	//SeverityError marks a problem that makes the .talismanrc file invalid
+	SeverityError Severity = "error"
+	//SeverityWarning marks a problem that is likely a mistake, but does not change how files are scanned
+	SeverityWarning Severity = "warning"
+)
+
+//Diagnostic describes a single problem found in a .talismanrc file.
+//Line is the line of the file the problem was found on, or 0 if it can't be attributed to a single line.
+//File is the path of a nested .talismanrc file, and is empty for the root .talismanrc.
+type Diagnostic struct {
+	Line     int
+	Severity Severity
+	Message  string
+	File     string
+}
+
+func (d Diagnostic) String() string {
+	file := d.File
+	if file == "" {
+		file = DefaultRCFileName
+	}
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", file, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", file, d.Severity, d.Message)
+}
+
+//Diagnostics is the list of problems found in a .talismanrc file
+type Diagnostics []Diagnostic
+
+//HasErrors answers if any of the problems makes the .talismanrc file invalid
+func (diagnostics Diagnostics) HasErrors() bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (diagnostics Diagnostics) String() string {
+	var lines []string
+	for _, diagnostic := range diagnostics {
+		lines = append(lines, diagnostic.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//ValidateTalismanRC checks the contents of a .talismanrc file for problems that would otherwise silently change how files are scanned.
+//File patterns are expected to match at least one of the supplied tracked files, and scopes to be one of the supplied scope names.
+func ValidateTalismanRC(fileContents []byte, trackedFiles []string, scopes map[string][]string) Diagnostics {
+	if trackedFiles == nil {
+		trackedFiles = []string{}
+	}
+	return append(validateTalismanRC(fileContents, trackedFiles, scopes), policyOnlyDiagnostics(fileContents)...)
+}
+
+//ValidatePolicyFile checks the organisation policy file found at source.
+//Its file patterns apply to every repository, so they are not expected to match any tracked file.
+func ValidatePolicyFile(source string, fileContents []byte, scopes map[string][]string) Diagnostics {
+	return inFile(source, validateTalismanRC(fileContents, nil, scopes))
+}
+
+//ValidateUserRCFile checks the user-global .talismanrc found at source.
+//Its file patterns apply to every repository, so they are not expected to match any tracked file.
+func ValidateUserRCFile(source string, fileContents []byte, scopes map[string][]string) Diagnostics {
+	return inFile(source, append(validateTalismanRC(fileContents, nil, scopes), policyOnlyDiagnostics(fileContents)...))
+}
+
+//ValidateNestedTalismanRC checks the .talismanrc file of a subdirectory, found at source, relative to the repository root.
+//The tracked files are expected to be relative to the directory of the file, just like its patterns.
+//Settings that are only read from the root .talismanrc are reported as well.
+func ValidateNestedTalismanRC(source string, fileContents []byte, trackedFiles []string, scopes map[string][]string) Diagnostics {
+	if trackedFiles == nil {
+		trackedFiles = []string{}
+	}
+	diagnostics := validateTalismanRC(fileContents, trackedFiles, scopes)
+	talismanRCIgnore := NewTalismanRCIgnore(fileContents)
+	rootOnlySettings := []struct {
+		key   string
+		isSet bool
+	}{
+		{"placeholderconfig", !reflect.DeepEqual(talismanRCIgnore.PlaceholderConfig, PlaceholderConfig{})},
+		{"dictionaryconfig", !reflect.DeepEqual(talismanRCIgnore.DictionaryConfig, DictionaryConfig{})},
+		{"expiryconfig", talismanRCIgnore.ExpiryConfig != ExpiryConfig{}},
+		{"detector_error_policy", talismanRCIgnore.DetectorErrorPolicy != ""},
+		{"require_ignore_reason", talismanRCIgnore.RequireIgnoreReason},
+		{"mandatory_detectors", len(talismanRCIgnore.MandatoryDetectors) > 0},
+		{"forbidden_ignores", len(talismanRCIgnore.ForbiddenIgnores) > 0},
+		{"custom_patterns", len(talismanRCIgnore.CustomPatterns) > 0},
+	}
+	for _, setting := range rootOnlySettings {
+		if setting.isSet {
+			diagnostics = append(diagnostics, Diagnostic{0, SeverityWarning, fmt.Sprintf("%s is only read from the root %s, and has no effect here", setting.key, DefaultRCFileName), ""})
+		}
+	}
+	return inFile(source, append(diagnostics, policyOnlyDiagnostics(fileContents)...))
+}
+
+//MissingReasons reports the fileignoreconfig entries without a reason, for when require_ignore_reason is set.
+//Source is the path of a nested .talismanrc file, and is empty for the root .talismanrc.
+func MissingReasons(source string, fileContents []byte) Diagnostics {
+	var diagnostics Diagnostics
+	fileNameLines := linesMatching(strings.Split(string(fileContents), "\n"), fileNameLinePattern)
+	for i, ignore := range NewTalismanRCIgnore(fileContents).FileIgnoreConfig {
+		if isEmptyString(ignore.Reason) {
+			diagnostics = append(diagnostics, Diagnostic{lineAt(fileNameLines, i), SeverityError, fmt.Sprintf("a reason is required to ignore %s", ignore.FileName), source})
+		}
+	}
+	return diagnostics
+}
+
+//policyOnlyDiagnostics warns about settings that only the organisation policy file can make
+func policyOnlyDiagnostics(fileContents []byte) Diagnostics {
+	if len(NewTalismanRCIgnore(fileContents).Locked) == 0 {
+		return nil
+	}
+	return Diagnostics{{0, SeverityWarning, "locked is only read from the organisation policy file, and has no effect here", ""}}
+}
+
+//inFile attributes the diagnostics to the file found at source
+func inFile(source string, diagnostics Diagnostics) Diagnostics {
+	for i := range diagnostics {
+		diagnostics[i].File = source
+	}
+	return diagnostics
+}
+
+//validateTalismanRC checks the contents of any kind of .talismanrc file.
+//File patterns are only checked against the tracked files if they are known, that is if trackedFiles is not nil.
+func validateTalismanRC(fileContents []byte, trackedFiles []string, scopes map[string][]string) Diagnostics {
+	var diagnostics Diagnostics
+	talismanRCIgnore := TalismanRCIgnore{}
+	if err := yaml.UnmarshalStrict(fileContents, &talismanRCIgnore); err != nil {
+		diagnostics = yamlDiagnostics(err)
+		if _, isTypeError := err.(*yaml.TypeError); !isTypeError {
+			//the file could not be parsed at all, so there is nothing more to check
+			return diagnostics
+		}
+	}
+
+	lines := strings.Split(string(fileContents), "\n")
+	fileNameLines := linesMatching(lines, fileNameLinePattern)
+	for i, ignore := range talismanRCIgnore.FileIgnoreConfig {
+		line := lineAt(fileNameLines, i)
+		if isEmptyString(ignore.FileName) {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityError, "filename is missing", ""})
+			continue
+		}
+		if ignore.Checksum != "" && !checksumPattern.MatchString(ignore.Checksum) {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityError, fmt.Sprintf("checksum %q of %s is not a SHA-256 checksum, as calculated by talisman --checksum", ignore.Checksum, ignore.FileName), ""})
+		}
+		if _, _, err := ignore.expiryDate(); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityError, err.Error(), ""})
+		}
+		for _, detectorName := range ignore.IgnoreDetectors {
+			if !contains(DetectorNames, detectorName) {
+				diagnostics = append(diagnostics, Diagnostic{line, SeverityError, fmt.Sprintf("unknown detector %q in ignore_detectors of %s, expected one of %s", detectorName, ignore.FileName, strings.Join(DetectorNames, ", ")), ""})
+			}
+		}
+		if trackedFiles != nil && !matchesAny(ignore.FileName, trackedFiles) {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityWarning, fmt.Sprintf("%s does not match any tracked file", ignore.FileName), ""})
+		}
+	}
+
+	scopeLines := linesMatching(lines, scopeLinePattern)
+	for i, scope := range talismanRCIgnore.ScopeConfig {
+		line := lineAt(scopeLines, i)
+		_, isBuiltIn := scopes[scope.ScopeName]
+		if isBuiltIn && len(scope.Paths) > 0 {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityError, fmt.Sprintf("scope %q is built in, so its paths can not be changed. Give the custom scope another name", scope.ScopeName), ""})
+		}
+		if !isBuiltIn && len(scope.Paths) == 0 {
+			diagnostics = append(diagnostics, Diagnostic{line, SeverityError, fmt.Sprintf("unknown scope %q, expected one of %s", scope.ScopeName, strings.Join(scopeNames(scopes), ", ")), ""})
+		}
+		for _, detectorName := range scope.IgnoreDetectors {
+			if !contains(DetectorNames, detectorName) {
+				diagnostics = append(diagnostics, Diagnostic{line, SeverityError, fmt.Sprintf("unknown detector %q in ignore_detectors of scope %s, expected one of %s", detectorName, scope.ScopeName, strings.Join(DetectorNames, ", ")), ""})
+			}
+		}
+		for _, pattern := range scope.Paths {
+			if trackedFiles != nil && !matchesAny(pattern, trackedFiles) {
+				diagnostics = append(diagnostics, Diagnostic{line, SeverityWarning, fmt.Sprintf("%s of scope %s does not match any tracked file", pattern, scope.ScopeName), ""})
+			}
+		}
+	}
+
+	if action := talismanRCIgnore.PlaceholderConfig.Action; action != "" && action != PlaceholderActionIgnore && action != PlaceholderActionWarn {
+		diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("unknown placeholder action %q, expected %s or %s", action, PlaceholderActionIgnore, PlaceholderActionWarn), ""})
+	}
+	if policy := talismanRCIgnore.DetectorErrorPolicy; policy != "" && policy != DetectorErrorPolicyFail && policy != DetectorErrorPolicyWarn {
+		diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("unknown detector_error_policy %q, expected %s or %s", policy, DetectorErrorPolicyFail, DetectorErrorPolicyWarn), ""})
+	}
+	if action := talismanRCIgnore.ExpiryConfig.Action; action != "" && action != ExpiryActionFail && action != ExpiryActionWarn {
+		diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("unknown expiry action %q, expected %s or %s", action, ExpiryActionFail, ExpiryActionWarn), ""})
+	}
+	for _, detectorName := range talismanRCIgnore.MandatoryDetectors {
+		if !contains(DetectorNames, detectorName) {
+			diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("unknown detector %q in mandatory_detectors, expected one of %s", detectorName, strings.Join(DetectorNames, ", ")), ""})
+		}
+	}
+	for _, customPattern := range talismanRCIgnore.CustomPatterns {
+		if _, err := regexp.Compile(customPattern); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("custom pattern %q is not a valid regular expression: %v", customPattern, err), ""})
+		}
+	}
+	for _, setting := range talismanRCIgnore.Locked {
+		if !contains(LockableSettings, setting) {
+			diagnostics = append(diagnostics, Diagnostic{0, SeverityError, fmt.Sprintf("%q can not be locked, expected one of %s", setting, strings.Join(LockableSettings, ", ")), ""})
+		}
+	}
+	return diagnostics
+}
+
+//yamlDiagnostics turns the errors reported by the yaml parser, such as "line 3: field foo not found in type ...", into diagnostics
+func yamlDiagnostics(err error) Diagnostics {
+	messages := []string{err.Error()}
+	if typeError, ok := err.(*yaml.TypeError); ok {
+		messages = typeError.Errors
+	}
+	var diagnostics Diagnostics
+	for _, message := range messages {
+		diagnostic := Diagnostic{0, SeverityError, strings.TrimPrefix(message, "yaml: "), ""}
+		if groups := yamlErrorLinePattern.FindStringSubmatch(message); groups != nil {
+			diagnostic.Line, _ = strconv.Atoi(groups[1])
+			diagnostic.Message = groups[2]
+		}
+		if strings.HasPrefix(diagnostic.Message, "field ") && strings.Contains(diagnostic.Message, " not found in type ") {
+			diagnostic.Message = fmt.Sprintf("unknown key %q", strings.Fields(diagnostic.Message)[1])
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+//linesMatching returns the 1 based numbers of the lines that match the pattern
+func linesMatching(lines []string, pattern *regexp.Regexp) []int {
+	var result []int
+	for i, line := range lines {
+		if pattern.MatchString(line) {
+			result = append(result, i+1)
+		}
+	}
+	return result
+}
+
+func lineAt(lines []int, index int) int {
+	if index < len(lines) {
+		return lines[index]
+	}
+	return 0
+}
+
+func matchesAny(pattern string, files []string) bool {
+	for _, file := range files {
+		if gitrepo.NewPattern(pattern).Matches(gitrepo.NewAddition(file, nil)) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeNames(scopes map[string][]string) []string {
+	var names []string
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,147 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testScopes = map[string][]string{"go": {"go.sum"}, "node": {"yarn.lock"}}
+
+func TestShouldFindNoProblemsInAValidTalismanRC(t *testing.T) {
+	talismanRC := `
+fileignoreconfig:
+- filename: private.pem
+  checksum: 1db800b79e6e9695adc451f77be974dc47bcd84d42873560d7767bfca30db8b1
+  ignore_detectors: [filename, filecontent]
+- filename: "*.lock"
+  ignore_detectors: [filesize]
+scopeconfig:
+- scope: go
+detector_error_policy: warn
+`
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"private.pem", "yarn.lock"}, testScopes)
+
+	assert.Empty(t, diagnostics)
+	assert.False(t, diagnostics.HasErrors())
+}
+
+func TestShouldReportYamlErrorsWithLineNumbers(t *testing.T) {
+	talismanRC := "fileignoreconfig:\n- filename: private.pem\n  checksum: [abc\n"
+
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), nil, testScopes)
+
+	if assert.Len(t, diagnostics, 1) {
+		assert.Equal(t, SeverityError, diagnostics[0].Severity)
+		assert.Equal(t, 3, diagnostics[0].Line)
+		assert.Contains(t, diagnostics[0].String(), ".talismanrc:3: error: ")
+	}
+}
+
+func TestShouldReportUnknownKeys(t *testing.T) {
+	talismanRC := "fileignoreconfig:\n- filename: private.pem\n  ignore_detector: [filename]\nscopeconfg:\n- scope: go\n"
+
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"private.pem"}, testScopes)
+
+	assert.Equal(t, Diagnostics{
+		{3, SeverityError, `unknown key "ignore_detector"`, ""},
+		{4, SeverityError, `unknown key "scopeconfg"`, ""},
+	}, diagnostics)
+}
+
+func TestShouldReportUnknownDetectorsMalformedChecksumsAndUnknownScopes(t *testing.T) {
+	talismanRC := `fileignoreconfig:
+- filename: private.pem
+  checksum: 05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c
+- filename: init-env.sh
+  ignore_detectors: [filename, file-content]
+scopeconfig:
+- scope: go
+- scope: java
+`
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"private.pem", "init-env.sh"}, testScopes)
+
+	assert.Equal(t, Diagnostics{
+		{2, SeverityError, `checksum "05db785bf1e1712f69b81eeb9956bd797b956e7179ebe3cb7bb2cd9be37a24c" of private.pem is not a SHA-256 checksum, as calculated by talisman --checksum`, ""},
+		{4, SeverityError, `unknown detector "file-content" in ignore_detectors of init-env.sh, expected one of filecontent, filename, filesize`, ""},
+		{8, SeverityError, `unknown scope "java", expected one of go, node`, ""},
+	}, diagnostics)
+}
+
+func TestShouldWarnAboutPatternsThatMatchNoTrackedFile(t *testing.T) {
+	talismanRC := "fileignoreconfig:\n- filename: old/*.pem\n  ignore_detectors: [filename]\n"
+
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"new/private.pem"}, testScopes)
+
+	assert.Equal(t, Diagnostics{{2, SeverityWarning, "old/*.pem does not match any tracked file", ""}}, diagnostics)
+	assert.False(t, diagnostics.HasErrors(), "Expected stale patterns not to make the file invalid")
+}
+
+func TestShouldReportInvalidPolicySettings(t *testing.T) {
+	policy := `mandatory_detectors: [filecontent, secrets]
+custom_patterns: ['acme_[0-9]+', 'broken(']
+locked: [fileignoreconfig, custom_patterns]
+fileignoreconfig:
+- filename: "*.lock"
+  ignore_detectors: [filesize]
+`
+	diagnostics := ValidatePolicyFile("/etc/talisman/policy.talismanrc", []byte(policy), testScopes)
+
+	if assert.Len(t, diagnostics, 3) {
+		assert.Contains(t, diagnostics[0].Message, `unknown detector "secrets" in mandatory_detectors`)
+		assert.Contains(t, diagnostics[1].Message, `custom pattern "broken(" is not a valid regular expression`)
+		assert.Contains(t, diagnostics[2].Message, `"custom_patterns" can not be locked`)
+		assert.Equal(t, "/etc/talisman/policy.talismanrc", diagnostics[0].File)
+	}
+}
+
+func TestShouldWarnAboutLockedSettingsOutsideOfThePolicy(t *testing.T) {
+	diagnostics := ValidateUserRCFile("/home/dev/.talismanrc", []byte("locked: [scopeconfig]\n"), testScopes)
+
+	assert.Equal(t, Diagnostics{
+		{0, SeverityWarning, "locked is only read from the organisation policy file, and has no effect here", "/home/dev/.talismanrc"},
+	}, diagnostics)
+	assert.False(t, diagnostics.HasErrors())
+}
+
+func TestShouldReportMalformedExpiryDates(t *testing.T) {
+	talismanRC := "fileignoreconfig:\n- filename: private.pem\n  expires: next week\nexpiryconfig:\n  action: ignore\n"
+
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"private.pem"}, testScopes)
+
+	assert.Equal(t, Diagnostics{
+		{2, SeverityError, `expires "next week" of private.pem is not a date such as 2006-01-02`, ""},
+		{0, SeverityError, `unknown expiry action "ignore", expected fail or warn`, ""},
+	}, diagnostics)
+}
+
+func TestShouldReportIgnoresWithoutAReason(t *testing.T) {
+	talismanRC := `fileignoreconfig:
+- filename: private.pem
+  ignore_detectors: [filename]
+  reason: test fixture
+- filename: id_rsa
+  ignore_detectors: [filename]
+  owner: payments-team
+`
+	assert.Equal(t, Diagnostics{
+		{5, SeverityError, "a reason is required to ignore id_rsa", "team/.talismanrc"},
+	}, MissingReasons("team/.talismanrc", []byte(talismanRC)))
+}
+
+func TestShouldReportMistakesInCustomScopes(t *testing.T) {
+	talismanRC := `scopeconfig:
+- scope: generated
+  paths: [gen/, old/]
+  ignore_detectors: [filecontent, entropy]
+- scope: go
+  paths: [tools/]
+`
+	diagnostics := ValidateTalismanRC([]byte(talismanRC), []string{"gen/api.pb.go", "tools/tools.go"}, testScopes)
+
+	assert.Equal(t, Diagnostics{
+		{2, SeverityError, `unknown detector "entropy" in ignore_detectors of scope generated, expected one of filecontent, filename, filesize`, ""},
+		{2, SeverityWarning, "old/ of scope generated does not match any tracked file", ""},
+		{5, SeverityError, `scope "go" is built in, so its paths can not be changed. Give the custom scope another name`, ""},
+	}, diagnostics)
+}
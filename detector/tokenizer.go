@@ -0,0 +1,324 @@
+package detector
+
+import (
+	"path/filepath"
+	"strings"
+
+	"talisman/gitrepo"
+)
+
+//token represents a single word that the content detectors check, along with the lines it starts and ends on.
+//A word joined from literals on several lines spans all of them.
+type token struct {
+	text        string
+	first, last int
+}
+
+//tokenizer splits the content of a file into the tokens that the content detectors check
+type tokenizer interface {
+	tokenize(content string) []token
+}
+
+//languageSyntax describes just enough of a language for its string literals and comments to be recognised
+type languageSyntax struct {
+	lineComments           []string
+	blockComments          [][2]string
+	blockCommentsLineStart bool
+	quotes                 []string
+	rawQuotes              []string
+	concatenation          []string
+	adjacentJoin           bool
+}
+
+var (
+	cStyleComments = [][2]string{{"/*", "*/"}}
+
+	goSyntax = languageSyntax{
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        []string{`"`, `'`},
+		rawQuotes:     []string{"`"},
+		concatenation: []string{"+"},
+	}
+	javaSyntax = languageSyntax{
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        []string{`"""`, `"`, `'`},
+		concatenation: []string{"+"},
+	}
+	javaScriptSyntax = languageSyntax{
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        []string{`"`, `'`, "`"},
+		concatenation: []string{"+"},
+	}
+	pythonSyntax = languageSyntax{
+		lineComments:  []string{"#"},
+		quotes:        []string{`"""`, `'''`, `"`, `'`},
+		concatenation: []string{"+", "\\"},
+		adjacentJoin:  true,
+	}
+	rubySyntax = languageSyntax{
+		lineComments:           []string{"#"},
+		blockComments:          [][2]string{{"=begin", "=end"}},
+		blockCommentsLineStart: true,
+		quotes:                 []string{`"`, `'`},
+		concatenation:          []string{"+", "<<", "\\"},
+	}
+	shellSyntax = languageSyntax{
+		lineComments:  []string{"#"},
+		quotes:        []string{`"`},
+		rawQuotes:     []string{`'`},
+		concatenation: []string{"\\"},
+	}
+	cSharpSyntax = languageSyntax{
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        []string{`"`, `'`},
+		rawQuotes:     []string{`@"`},
+		concatenation: []string{"+"},
+	}
+
+	syntaxByExtension = map[string]languageSyntax{
+		".go":   goSyntax,
+		".java": javaSyntax,
+		".kt":   javaSyntax,
+		".js":   javaScriptSyntax,
+		".jsx":  javaScriptSyntax,
+		".mjs":  javaScriptSyntax,
+		".ts":   javaScriptSyntax,
+		".tsx":  javaScriptSyntax,
+		".py":   pythonSyntax,
+		".rb":   rubySyntax,
+		".sh":   shellSyntax,
+		".bash": shellSyntax,
+		".zsh":  shellSyntax,
+		".cs":   cSharpSyntax,
+	}
+)
+
+//addedTokens keeps the tokens on the lines that the addition adds, so that content which is already committed is not reported again
+func addedTokens(addition gitrepo.Addition, tokens []token) []token {
+	if addition.AddedLines == nil {
+		return tokens
+	}
+	var result []token
+	for _, token := range tokens {
+		if addition.Adds(token.first, token.last) {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+//tokenizerFor returns the tokenizer for the language of the supplied file, chosen by its extension.
+//Files of unknown languages are split on whitespace.
+func tokenizerFor(fileName gitrepo.FileName) tokenizer {
+	if syntax, ok := syntaxByExtension[strings.ToLower(filepath.Ext(string(fileName)))]; ok {
+		return syntax
+	}
+	return whitespaceTokenizer{}
+}
+
+//whitespaceTokenizer splits each line of the content on whitespace
+type whitespaceTokenizer struct{}
+
+func (whitespaceTokenizer) tokenize(content string) []token {
+	var tokens []token
+	for lineIndex, line := range strings.Split(content, "\n") {
+		tokens = append(tokens, fields(line, lineIndex+1, lineIndex+1)...)
+	}
+	return tokens
+}
+
+//tokenize splits the code, string literals and comment text of the content into words.
+//Literals that are concatenated with each other are joined, so that secrets split across them are checked as a whole.
+func (syntax languageSyntax) tokenize(content string) []token {
+	scanner := &literalScanner{syntax: syntax, content: content, line: 1}
+	return scanner.scan()
+}
+
+type literalScanner struct {
+	syntax  languageSyntax
+	content string
+	offset  int
+	line    int
+	tokens  []token
+	code    strings.Builder
+}
+
+func (s *literalScanner) scan() []token {
+	for s.offset < len(s.content) {
+		line := s.line
+		if end, ok := s.startsBlockComment(); ok {
+			s.flushCode()
+			s.addText(s.readUntil(end), line, line)
+		} else if comment := s.startsWithAny(s.syntax.lineComments); comment != "" {
+			s.flushCode()
+			s.skip(len(comment))
+			s.addText(s.readUntil("\n"), line, line)
+		} else if quote, raw := s.startsLiteral(); quote != "" {
+			s.flushCode()
+			s.readLiteralChain(quote, raw)
+		} else {
+			s.advanceCode()
+		}
+	}
+	s.flushCode()
+	return s.tokens
+}
+
+func (s *literalScanner) startsBlockComment() (string, bool) {
+	if s.syntax.blockCommentsLineStart && s.offset > 0 && s.content[s.offset-1] != '\n' {
+		return "", false
+	}
+	for _, comment := range s.syntax.blockComments {
+		if strings.HasPrefix(s.content[s.offset:], comment[0]) {
+			s.skip(len(comment[0]))
+			if s.syntax.blockCommentsLineStart {
+				return "\n" + comment[1], true
+			}
+			return comment[1], true
+		}
+	}
+	return "", false
+}
+
+func (s *literalScanner) startsLiteral() (string, bool) {
+	if quote := s.startsWithAny(s.syntax.rawQuotes); quote != "" {
+		return quote, true
+	}
+	return s.startsWithAny(s.syntax.quotes), false
+}
+
+func (s *literalScanner) startsWithAny(prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s.content[s.offset:], prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+//readLiteralChain reads the literal starting at the current offset along with every literal concatenated to it
+func (s *literalScanner) readLiteralChain(quote string, raw bool) {
+	line := s.line
+	literal := strings.Builder{}
+	for {
+		s.skip(len(quote))
+		literal.WriteString(s.readLiteral(closingQuote(quote), raw))
+		next, nextRaw, ok := s.concatenatedLiteral()
+		if !ok {
+			break
+		}
+		quote, raw = next, nextRaw
+	}
+	s.addText(literal.String(), line, s.line)
+}
+
+//concatenatedLiteral moves past a concatenation operator if another literal follows it
+func (s *literalScanner) concatenatedLiteral() (string, bool, bool) {
+	offset, line := s.offset, s.line
+	s.skipWhitespace()
+	joined := s.syntax.adjacentJoin && s.offset > offset
+	if operator := s.startsWithAny(s.syntax.concatenation); operator != "" {
+		s.skip(len(operator))
+		s.skipWhitespace()
+		joined = true
+	}
+	if quote, raw := s.startsLiteral(); quote != "" && joined {
+		return quote, raw, true
+	}
+	s.offset, s.line = offset, line
+	return "", false, false
+}
+
+func (s *literalScanner) readLiteral(quote string, raw bool) string {
+	literal := strings.Builder{}
+	for s.offset < len(s.content) {
+		if strings.HasPrefix(s.content[s.offset:], quote) {
+			s.skip(len(quote))
+			return literal.String()
+		}
+		if !raw && s.content[s.offset] == '\\' && s.offset+1 < len(s.content) {
+			s.skip(1)
+		}
+		if len(quote) == 1 && !raw && s.content[s.offset] == '\n' {
+			return literal.String()
+		}
+		literal.WriteByte(s.content[s.offset])
+		s.skip(1)
+	}
+	return literal.String()
+}
+
+func (s *literalScanner) readUntil(end string) string {
+	text := s.content[s.offset:]
+	length := strings.Index(text, end)
+	if length < 0 {
+		length = len(text)
+	}
+	s.skip(length)
+	if strings.HasPrefix(s.content[s.offset:], end) && end != "\n" {
+		s.skip(len(end))
+	}
+	return text[:length]
+}
+
+func (s *literalScanner) advanceCode() {
+	s.code.WriteByte(s.content[s.offset])
+	if s.content[s.offset] == '\n' {
+		s.flushCode()
+	}
+	s.skip(1)
+}
+
+func (s *literalScanner) flushCode() {
+	if s.code.Len() > 0 {
+		s.addText(s.code.String(), s.line, s.line)
+		s.code.Reset()
+	}
+}
+
+func (s *literalScanner) skipWhitespace() {
+	for s.offset < len(s.content) && strings.ContainsRune(" \t\r\n", rune(s.content[s.offset])) {
+		s.skip(1)
+	}
+}
+
+func (s *literalScanner) skip(length int) {
+	for i := 0; i < length && s.offset < len(s.content); i++ {
+		if s.content[s.offset] == '\n' {
+			s.line++
+		}
+		s.offset++
+	}
+}
+
+//addText adds the words of the text that starts on the first line.
+//The words of its last line end on the last line, if that is further on, as they do for joined literals.
+func (s *literalScanner) addText(text string, first int, last int) {
+	textLines := strings.Split(text, "\n")
+	for lineIndex, textLine := range textLines {
+		lineNumber, lastLine := first+lineIndex, first+lineIndex
+		if lineIndex == len(textLines)-1 && last > lastLine {
+			lastLine = last
+		}
+		s.tokens = append(s.tokens, fields(textLine, lineNumber, lastLine)...)
+	}
+}
+
+func closingQuote(quote string) string {
+	if quote == `@"` {
+		return `"`
+	}
+	return quote
+}
+
+func fields(line string, first int, last int) []token {
+	var tokens []token
+	for _, word := range strings.Fields(line) {
+		tokens = append(tokens, token{text: word, first: first, last: last})
+	}
+	return tokens
+}
@@ -0,0 +1,83 @@
+package detector
+
+import (
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldFallBackToWhitespaceTokenizerForUnknownFiles(t *testing.T) {
+	tokens := tokenizerFor("notes.txt").tokenize("accessKey=\"abc\"; other\nsecond line")
+
+	assert.Equal(t, []token{{"accessKey=\"abc\";", 1, 1}, {"other", 1, 1}, {"second", 2, 2}, {"line", 2, 2}}, tokens)
+}
+
+func TestShouldTokenizeCodeStringLiteralsAndCommentsFromJava(t *testing.T) {
+	code := "class A {\n    // the key\n    String key = \"abc\"; /* multi\n line */\n}"
+
+	tokens := tokenizerFor("A.java").tokenize(code)
+
+	assert.Equal(t, []token{{"class", 1, 1}, {"A", 1, 1}, {"{", 1, 1}, {"the", 2, 2}, {"key", 2, 2}, {"String", 3, 3}, {"key", 3, 3}, {"=", 3, 3}, {"abc", 3, 3}, {";", 3, 3}, {"multi", 3, 3}, {"line", 4, 4}, {"}", 5, 5}}, tokens)
+}
+
+func TestShouldJoinConcatenatedStringLiterals(t *testing.T) {
+	assert.Equal(t, []token{{"const", 1, 1}, {"key", 1, 1}, {"=", 1, 1}, {"wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", 1, 2}, {";", 2, 2}}, tokenizerFor("a.js").tokenize("const key = \"wJalrXUtnFEMI/\" +\n  'K7MDENG/bPxRfiCYEXAMPLEKEY';"))
+	assert.Equal(t, []token{{"key", 1, 1}, {"=", 1, 1}, {"(", 1, 1}, {"wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", 1, 2}, {")", 2, 2}}, tokenizerFor("a.py").tokenize("key = (\"wJalrXUtnFEMI/\"\n       \"K7MDENG/bPxRfiCYEXAMPLEKEY\")"))
+	assert.Equal(t, []token{{"call(", 1, 1}, {"a", 1, 1}, {",", 1, 1}, {"b", 1, 1}, {")", 1, 1}}, tokenizerFor("a.go").tokenize("call(\"a\", `b`)"))
+}
+
+func TestShouldHonourEscapesAndRawStrings(t *testing.T) {
+	assert.Equal(t, []token{{"var", 1, 1}, {"s", 1, 1}, {"=", 1, 1}, {"say\"hi\"", 1, 1}, {";", 1, 1}}, tokenizerFor("a.cs").tokenize("var s = \"say\\\"hi\\\"\";"))
+	assert.Equal(t, []token{{"var", 1, 1}, {"s", 1, 1}, {"=", 1, 1}, {"C:\\path", 1, 1}, {";", 1, 1}}, tokenizerFor("a.cs").tokenize("var s = @\"C:\\path\";"))
+}
+
+func TestShouldKeepUnquotedValuesInCode(t *testing.T) {
+	tokens := tokenizerFor("main.go").tokenize("card := 4111111111111111")
+
+	assert.Equal(t, []token{{"card", 1, 1}, {":=", 1, 1}, {"4111111111111111", 1, 1}}, tokens)
+}
+
+func TestShouldOnlyStartRubyBlockCommentsAtTheStartOfALine(t *testing.T) {
+	code := "x = 1 =begin\n=begin\nhidden =end\n=end\ny"
+
+	tokens := tokenizerFor("a.rb").tokenize(code)
+
+	assert.Equal(t, []token{{"x", 1, 1}, {"=", 1, 1}, {"1", 1, 1}, {"=begin", 1, 1}, {"hidden", 3, 3}, {"=end", 3, 3}, {"y", 5, 5}}, tokens)
+}
+
+func TestShouldKeepUnquotedWordsInShellScripts(t *testing.T) {
+	tokens := tokenizerFor("deploy.sh").tokenize("# deploy\nexport KEY=abc123 'quoted'")
+
+	assert.Equal(t, []token{{"deploy", 1, 1}, {"export", 2, 2}, {"KEY=abc123", 2, 2}, {"quoted", 2, 2}}, tokens)
+}
+
+func TestShouldFlagSecretsSplitAcrossConcatenatedLiterals(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("String key = \"wJalrXUtnFEMI/K7MDENG\" +\n    \"/bPxRfiCYEXAMPLEKEY\";")
+	additions := []gitrepo.Addition{gitrepo.NewAddition("Config.java", content)}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures(), "Expected the concatenated secret to be detected")
+	assert.Equal(t, "Expected file to not to contain base64 encoded texts such as: wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", getFailureMessages(results, additions[0].Path)[0])
+}
+
+func TestShouldFlagSecretsAddedOnAContinuationLineOfAConcatenation(t *testing.T) {
+	results := NewDetectionResults()
+	addition := gitrepo.NewAddition("Config.java", []byte("String key = \"wJalrXUtnFEMI/K7MDENG\" +\n    \"/bPxRfiCYEXAMPLEKEY\";"))
+	addition.AddedLines = []gitrepo.LineRange{{First: 2, Last: 2}}
+
+	NewFileContentDetector().Test([]gitrepo.Addition{addition}, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures(), "Expected the secret completed on an added line to be detected")
+}
+
+func TestShouldFlagUnquotedCreditCardNumbersInCode(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []gitrepo.Addition{gitrepo.NewAddition("main.go", []byte("card := 4111111111111111"))}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures(), "Expected the unquoted credit card number to be detected")
+}
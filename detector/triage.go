@@ -0,0 +1,129 @@
+package detector
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"talisman/gitrepo"
+	"talisman/prompt"
+)
+
+//The choices the user has for every failure when triaging them interactively
+const (
+	ignoreFindingChoice  = "Ignore this finding"
+	ignoreFileChoice     = "Ignore the file with a checksum"
+	allowlistValueChoice = "Add the value to the allowlist"
+	showLinesChoice      = "Show the surrounding lines"
+	keepFailingChoice    = "Keep it failing"
+	abortChoice          = "Abort"
+
+	//surroundingLines is the number of lines shown above and below a detected value
+	surroundingLines = 3
+)
+
+//triage holds what the user chose to add to .talismanrc for the failures
+type triage struct {
+	entries     []FileIgnoreConfig
+	dummyValues []string
+}
+
+//triageFailures asks the user what to do about every failure of the files, one by one.
+//It answers false if the user aborted, in which case nothing should be added to .talismanrc.
+func (r *DetectionResults) triageFailures(filePaths []string, promptContext prompt.PromptContext) (triage, bool) {
+	var result triage
+	for _, filePath := range filePaths {
+		ignoredDetectors := []string{}
+		var ignoredFailure Details
+		ignoredFile := false
+		for _, failure := range r.GetFailures(gitrepo.FilePath(filePath)) {
+			if failure.Category == ExpiryCategory {
+				continue
+			}
+			fmt.Printf("\n%s: %s\n", filePath, failure.Message)
+			switch r.choose(filePath, failure, promptContext) {
+			case ignoreFindingChoice:
+				if failure.Value != "" {
+					result.allowlist(failure.Value)
+				} else if !contains(ignoredDetectors, failure.Category) {
+					ignoredDetectors = append(ignoredDetectors, failure.Category)
+				}
+				ignoredFailure = failure
+			case ignoreFileChoice:
+				result.entries = append(result.entries, r.suggestedEntryFor(filePath, failure))
+				ignoredFile = true
+			case allowlistValueChoice:
+				result.allowlist(failure.Value)
+			case abortChoice:
+				return triage{}, false
+			}
+			if ignoredFile {
+				break
+			}
+		}
+		if !ignoredFile && len(ignoredDetectors) > 0 {
+			entry := r.suggestedEntryFor(filePath, ignoredFailure)
+			entry.IgnoreDetectors = ignoredDetectors
+			result.entries = append(result.entries, entry)
+		}
+	}
+	return result, true
+}
+
+//allowlist adds the detected value, as it was detected, to the dummy values
+func (t *triage) allowlist(value string) {
+	if value = strings.TrimSpace(value); value != "" && !contains(t.dummyValues, value) {
+		t.dummyValues = append(t.dummyValues, value)
+	}
+}
+
+//choose asks the user what to do about the failure, showing the lines around its value for as long as they ask for them
+func (r *DetectionResults) choose(filePath string, failure Details, promptContext prompt.PromptContext) string {
+	choices := []string{}
+	if contains(DetectorNames, failure.Category) {
+		choices = append(choices, ignoreFindingChoice)
+	}
+	choices = append(choices, ignoreFileChoice)
+	if strings.TrimSpace(failure.Value) != "" {
+		choices = append(choices, allowlistValueChoice)
+	}
+	if _, scanned := r.scannedAdditionOf(filePath, failure); scanned && failure.Value != "" {
+		choices = append(choices, showLinesChoice)
+	}
+	choices = append(choices, keepFailingChoice, abortChoice)
+	for {
+		choice := promptContext.Prompt.Select("What should be done about this finding?", choices, keepFailingChoice)
+		if choice != showLinesChoice {
+			return choice
+		}
+		r.printSurroundingLines(filePath, failure)
+	}
+}
+
+//printSurroundingLines prints the lines around the first line that has the value of the failure, in the scanned content it was found in
+func (r *DetectionResults) printSurroundingLines(filePath string, failure Details) {
+	addition, _ := r.scannedAdditionOf(filePath, failure)
+	content, err := addition.Content()
+	value := failure.Value
+	if err != nil {
+		log.Printf("error reading %s: %s", filePath, err)
+		return
+	}
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, value) {
+			continue
+		}
+		for j := i - surroundingLines; j <= i+surroundingLines; j++ {
+			if j < 0 || j >= len(lines) {
+				continue
+			}
+			marker := " "
+			if j == i {
+				marker = ">"
+			}
+			fmt.Printf("%s %4d | %s\n", marker, j+1, lines[j])
+		}
+		return
+	}
+	fmt.Printf("The value could not be found on a single line of %s\n", filePath)
+}
@@ -1,48 +1,145 @@
 package detector
 
 import (
-	"bufio"
-	"os"
+	"io/ioutil"
+	"sort"
 	"strings"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+//WordCheck answers whether a piece of text is made up of dictionary words only.
+//The built in dictionary is always consulted, followed by any extra dictionaries configured for the repository.
+//A nil WordCheck consults only the built in dictionary.
 type WordCheck struct {
+	extraDictionary *dictionary
+}
+
+//DictionaryConfig lists extra domain words, such as product and library names, that should not be mistaken for secrets
+type DictionaryConfig struct {
+	Words []string `yaml:"words,omitempty"`
+	Files []string `yaml:"files,omitempty"`
 }
 
 const AVERAGE_LENGTH_OF_WORDS_IN_ENGLISH = 5 //See http://bit.ly/2qYFzFf for reference
 
+var (
+	builtInDictionary     *dictionary
+	builtInDictionaryOnce sync.Once
+)
+
+//dictionary is a prebuilt lookup structure over a list of words.
+//Every word remembers the positions it occupies in the list, since words are matched in list order.
+type dictionary struct {
+	automaton *ahoCorasick
+	words     []string
+	positions [][]int
+	//end is the position right after the last word of the list, where a following dictionary starts numbering
+	end int
+}
+
+//NewWordCheck returns a WordCheck that also consults the words and word files listed in the supplied config
+func NewWordCheck(config DictionaryConfig) *WordCheck {
+	words := append([]string{}, config.Words...)
+	for _, file := range config.Files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"file":  file,
+				"error": err,
+			}).Error("Unable to read dictionary file")
+			continue
+		}
+		words = append(words, strings.Split(string(contents), "\n")...)
+	}
+	for i, word := range words {
+		words[i] = strings.ToLower(strings.TrimSpace(word))
+	}
+	if len(words) == 0 {
+		return &WordCheck{}
+	}
+	return &WordCheck{extraDictionary: newDictionary(words, getBuiltInDictionary().end)}
+}
+
+func getBuiltInDictionary() *dictionary {
+	builtInDictionaryOnce.Do(func() {
+		builtInDictionary = newDictionary(strings.Split(DictionaryWordsString, "\n"), 0)
+	})
+	return builtInDictionary
+}
+
+//newDictionary builds a dictionary of the words that can ever match, numbering their positions from the supplied offset.
+//Words of two letters or less are skipped, as are words with upper case letters, since text is always matched in lower case.
+func newDictionary(wordList []string, offset int) *dictionary {
+	d := &dictionary{end: offset + len(wordList)}
+	indexes := map[string]int{}
+	for position, word := range wordList {
+		if len(word) <= 2 || strings.ToLower(word) != word {
+			continue
+		}
+		index, ok := indexes[word]
+		if !ok {
+			index = len(d.words)
+			indexes[word] = index
+			d.words = append(d.words, word)
+			d.positions = append(d.positions, nil)
+		}
+		d.positions[index] = append(d.positions[index], offset+position)
+	}
+	d.automaton = newAhoCorasick(d.words)
+	return d
+}
+
+//firstMatch returns the word at the earliest position not before the supplied one that occurs in the text
+func (d *dictionary) firstMatch(text string, from int) (string, int) {
+	bestWord, bestPosition := "", -1
+	d.automaton.findAll(text, func(index int, start int) {
+		positions := d.positions[index]
+		next := sort.SearchInts(positions, from)
+		if next < len(positions) && (bestPosition < 0 || positions[next] < bestPosition) {
+			bestWord, bestPosition = d.words[index], positions[next]
+		}
+	})
+	return bestWord, bestPosition
+}
+
+func (en *WordCheck) dictionaries() []*dictionary {
+	if en == nil || en.extraDictionary == nil {
+		return []*dictionary{getBuiltInDictionary()}
+	}
+	return []*dictionary{getBuiltInDictionary(), en.extraDictionary}
+}
+
 func (en *WordCheck) containsWordsOnly(text string) bool {
 	text = strings.ToLower(text)
-	file := &os.File{}
-	defer file.Close()
-	reader := bufio.NewReader(strings.NewReader(DictionaryWordsString))
-	if reader == nil {
-		return false
-	}
-	wordCount := howManyWordsExistInText(reader, text)
+	wordCount := howManyWordsExistInText(en.dictionaries(), text)
 	if wordCount >= (len(text) / (AVERAGE_LENGTH_OF_WORDS_IN_ENGLISH)) {
 		return true
 	}
 	return false
 }
 
-func howManyWordsExistInText(reader *bufio.Reader, text string) int {
+//howManyWordsExistInText walks the dictionaries in order, removing the first occurrence of every word found in the text.
+//Removing a word can join the remaining text into a new word, so the text is searched again after every removal,
+//starting from the position in the dictionaries right after the removed word.
+func howManyWordsExistInText(dictionaries []*dictionary, text string) int {
 	wordCount := 0
+	position := 0
 	for {
-		word, err := reader.ReadString('\n')
-		word = strings.Trim(word, "\n")
-
-		if word != "" && len(word) > 2 && strings.Contains(text, word) {
-			text = strings.Replace(text, word, "", 1) //already matched
-			wordCount++
+		word, wordPosition := "", -1
+		for _, d := range dictionaries {
+			if word, wordPosition = d.firstMatch(text, position); wordPosition >= 0 {
+				break
+			}
 		}
-
-		if err != nil { //EOF
-			log.Debugf("[WordChecker]: Found %d words", wordCount)
+		if wordPosition < 0 {
 			break
 		}
+		text = strings.Replace(text, word, "", 1) //already matched
+		wordCount++
+		position = wordPosition + 1
 	}
+	log.Debugf("[WordChecker]: Found %d words", wordCount)
 	return wordCount
 }
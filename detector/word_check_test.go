@@ -1,6 +1,8 @@
 package detector
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,3 +55,36 @@ func TestWordCheckWithHalfWordsHalfSecret(t *testing.T) {
 	isWordsOnly := wc.containsWordsOnly("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEYhelloWorldGreetingsFromThoughtWorks")
 	assert.False(t, isWordsOnly)
 }
+
+func TestWordCheckWithDomainWordsFromExtraDictionary(t *testing.T) {
+	text := "zxqjKafkaZxqjGrafanaQzxjKeycloakZxqjPrometheus"
+	assert.False(t, (&WordCheck{}).containsWordsOnly(text))
+
+	wc := NewWordCheck(DictionaryConfig{Words: []string{"Kafka", "Grafana", "Keycloak", "Prometheus", "zxqj", "qzxj"}})
+	assert.True(t, wc.containsWordsOnly(text))
+}
+
+func TestWordCheckWithExtraDictionaryFile(t *testing.T) {
+	file, err := ioutil.TempFile(os.TempDir(), "dictionary")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("Kafka\nGrafana\nKeycloak\nPrometheus\nzxqj\nqzxj\n")
+	file.Close()
+
+	wc := NewWordCheck(DictionaryConfig{Files: []string{file.Name()}})
+	assert.True(t, wc.containsWordsOnly("zxqjKafkaZxqjGrafanaQzxjKeycloakZxqjPrometheus"))
+}
+
+func TestWordCheckConsultsExtraDictionaryAfterLateBuiltInMatch(t *testing.T) {
+	wc := NewWordCheck(DictionaryConfig{Words: []string{"qzkxj", "wqzxj", "vqzxj"}})
+	assert.True(t, wc.containsWordsOnly("zymurgyqzkxjwqzxj"))
+}
+
+func BenchmarkWordCheck(b *testing.B) {
+	wc := WordCheck{}
+	wc.containsWordsOnly("warmup")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wc.containsWordsOnly("TestBase64DetectorShouldNotDetectLongMethodNamesEvenWithRidiculousHighEntropyWordsMightExist")
+	}
+}
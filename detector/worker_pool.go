@@ -0,0 +1,50 @@
+package detector
+
+import (
+	"runtime"
+	"sync"
+	"talisman/gitrepo"
+)
+
+//DefaultJobs is the number of additions a detector examines concurrently, unless configured otherwise
+var DefaultJobs = runtime.NumCPU()
+
+//jobsOrDefault returns the configured number of jobs, falling back to DefaultJobs when it is not positive
+func jobsOrDefault(jobs int) int {
+	if jobs <= 0 {
+		return DefaultJobs
+	}
+	return jobs
+}
+
+//forEachAddition calls work for every addition from at most the supplied number of goroutines.
+//Additions that work returns an error or panics for are handed to failed, from the same goroutine.
+//It returns immediately, and calls done once every addition has been worked on,
+//so that the caller can consume whatever the workers produce in the meantime.
+func forEachAddition(additions []gitrepo.Addition, jobs int, work func(addition gitrepo.Addition) error, failed func(addition gitrepo.Addition, err error), done func()) {
+	jobs = jobsOrDefault(jobs)
+	if jobs > len(additions) {
+		jobs = len(additions)
+	}
+	queue := make(chan gitrepo.Addition)
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer waitGroup.Done()
+			for addition := range queue {
+				if err := examine(addition, work); err != nil {
+					failed(addition, err)
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, addition := range additions {
+			queue <- addition
+		}
+		close(queue)
+		waitGroup.Wait()
+		done()
+	}()
+}
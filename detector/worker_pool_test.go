@@ -0,0 +1,92 @@
+package detector
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldWorkOnEveryAdditionWithBoundedConcurrency(t *testing.T) {
+	var additions []gitrepo.Addition
+	for i := 0; i < 50; i++ {
+		additions = append(additions, gitrepo.NewAddition("file.txt", []byte{}))
+	}
+	lock := sync.Mutex{}
+	running, maxRunning, worked := 0, 0, 0
+	done := make(chan bool)
+
+	forEachAddition(additions, 3, func(addition gitrepo.Addition) error {
+		lock.Lock()
+		running++
+		worked++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	}, nil, func() { close(done) })
+	<-done
+
+	assert.Equal(t, 50, worked)
+	assert.True(t, maxRunning <= 3, "Expected at most 3 workers, but %d were running at once", maxRunning)
+}
+
+func TestShouldFinishWhenThereAreNoAdditions(t *testing.T) {
+	done := make(chan bool)
+
+	forEachAddition(nil, 0, func(addition gitrepo.Addition) error { return nil }, nil, func() { close(done) })
+
+	<-done
+}
+
+func TestShouldHandAdditionsThatFailOrPanicToFailed(t *testing.T) {
+	additions := []gitrepo.Addition{gitrepo.NewAddition("fails.txt", []byte{}), gitrepo.NewAddition("panics.txt", []byte{}), gitrepo.NewAddition("works.txt", []byte{})}
+	lock := sync.Mutex{}
+	failures := map[gitrepo.FilePath]string{}
+	done := make(chan bool)
+
+	forEachAddition(additions, 2, func(addition gitrepo.Addition) error {
+		if addition.Path == "fails.txt" {
+			return errors.New("unreadable")
+		}
+		if addition.Path == "panics.txt" {
+			var detections []string
+			_ = detections[1]
+		}
+		return nil
+	}, func(addition gitrepo.Addition, err error) {
+		lock.Lock()
+		failures[addition.Path] = err.Error()
+		lock.Unlock()
+	}, func() { close(done) })
+	<-done
+
+	assert.Len(t, failures, 2)
+	assert.Equal(t, "unreadable", failures["fails.txt"])
+	assert.Contains(t, failures["panics.txt"], "index out of range")
+}
+
+func TestShouldReadLazyAdditionsOnlyWhenTheirContentIsNeeded(t *testing.T) {
+	opened := 0
+	open := func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(bytes.NewReader([]byte("password=UnsafePassword"))), nil
+	}
+	additions := []gitrepo.Addition{gitrepo.NewLazyAddition("secret.txt", nil, open)}
+	results := NewDetectionResults()
+
+	assert.Equal(t, 0, opened)
+	NewPatternDetector().WithJobs(1).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.Equal(t, 1, opened)
+	assert.True(t, results.HasFailures(), "Expected the lazily read secret to be detected")
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"io"
 	"io/ioutil"
+	"os"
 	"talisman/gitrepo"
 
 	log "github.com/Sirupsen/logrus"
@@ -20,19 +22,25 @@ func (p *DirectoryHook) GetFilesFromDirectory(globPattern string) []gitrepo.Addi
 
 	files, _ := doublestar.Glob(globPattern)
 	for _, file := range files {
-		data, err := ReadFile(file)
-
-		if err != nil {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
 			continue
 		}
 
-		newAddition := gitrepo.NewAddition(file, data)
+		newAddition := gitrepo.NewLazyAddition(file, nil, OpenFile(file))
 		result = append(result, newAddition)
 	}
 
 	return result
 }
 
+//OpenFile returns a function that opens the file at the supplied path, so that it is read only when its contents are needed
+func OpenFile(filepath string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		log.Debugf("opening file %s", filepath)
+		return os.Open(filepath)
+	}
+}
+
 func ReadFile(filepath string) ([]byte, error) {
 	log.Debugf("reading file %s", filepath)
 	return ioutil.ReadFile(filepath)
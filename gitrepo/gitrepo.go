@@ -1,15 +1,19 @@
 package gitrepo
 
 import (
+	"bytes"
 	"fmt"
 	log "github.com/Sirupsen/logrus"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"talisman/utility"
 )
 
 //FilePath represents the absolute path of an added file
@@ -18,14 +22,46 @@ type FilePath string
 //FileName represents the base name of an added file
 type FileName string
 
-//Addition represents the end state of a file
+//Addition represents the end state of a file.
+//The contents of an addition are either held in Data, or read on demand through the reader it was created with.
+//Contents read on demand are not kept once they have been used, so that only the additions being examined are held in memory.
 type Addition struct {
 	Path    FilePath
 	Name    FileName
 	Commits []string
 	Data    []byte
+	//Refs are the pushed refs that the addition is part of
+	Refs []string
+	//Blob is the hash of the git object that the contents are read from, if they are read from one
+	Blob string
+	//AddedLines are the lines of the contents that the change being scanned adds or changes.
+	//Every line is taken to be added when it is nil, as it is for additions that are scanned in full.
+	AddedLines []LineRange
+	//CommitMessage is set for additions that hold the message of a commit rather than the contents of a file
+	CommitMessage bool
+	open          func() (io.ReadCloser, error)
+	//size returns the size of the contents without reading them, where that is possible
+	size func() (int64, error)
 }
 
+//LineRange is a range of lines, numbered from 1, that includes both its first and last line
+type LineRange struct {
+	First int
+	Last  int
+}
+
+//hunkHeader matches the header of a hunk of git diff, capturing the first line and the number of lines of its new side
+var hunkHeader = regexp.MustCompile(`(?m)^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+//binaryPatch matches the line that git diff writes instead of the changed lines of a binary file
+var binaryPatch = regexp.MustCompile(`(?m)^Binary files .* differ$`)
+
+//CommitMessagePath is the path that commit messages are scanned and reported as, named after the file git keeps the message of a commit being made in
+const CommitMessagePath = "COMMIT_EDITMSG"
+
+//submoduleMode is the mode of the entries of a git tree that refer to the commit of a submodule rather than a blob
+const submoduleMode = "160000"
+
 //GitRepo represents a Git repository located at the absolute path represented by root
 type GitRepo struct {
 	root string
@@ -38,60 +74,25 @@ func RepoLocatedAt(path string) GitRepo {
 	return GitRepo{absoluteRoot}
 }
 
-// GetDiffForStagedFiles gets all the staged files and collects the diff section in each file
-func (repo GitRepo) GetDiffForStagedFiles() []Addition {
-	stagedContent := repo.executeRepoCommand("git", "diff", "--staged")
-	content := strings.TrimSpace(string(stagedContent))
-	lines := strings.Split(content, "\n")
-	result := make([]Addition, 0)
-
-	if len(lines) < 1 {
-		return result
+//GetDiffForStagedFiles returns an addition for every file staged for commit, holding its whole staged content along with the lines that the staged changes add to it.
+//Every line of a new, renamed, copied or binary file is taken to be added, so such a file is scanned in full even if its content is unchanged.
+//Renamed and copied files are named by their new path, so that filename detection sees it.
+func (repo GitRepo) GetDiffForStagedFiles() ([]Addition, error) {
+	changes, err := repo.stagedChanges()
+	if err != nil {
+		return nil, err
 	}
-
-	// Standard git diff header pattern
-	// ref: https://git-scm.com/docs/diff-format#_generating_patches_with_p
-	headerRegex := regexp.MustCompile("^diff --git a/([^ ]+) ")
-
-	lineNumberOfFirstHeader := 0
-	var additionFilename string
-	for ; lineNumberOfFirstHeader < len(lines); lineNumberOfFirstHeader++ {
-		if headerRegex.MatchString(lines[lineNumberOfFirstHeader]) {
-			matches := headerRegex.FindStringSubmatch(lines[lineNumberOfFirstHeader])
-			additionFilename = matches[1]
-			break
+	result := make([]Addition, 0)
+	for _, change := range changes {
+		addedLines, err := repo.addedLines(change)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	additionContentBuffer := &strings.Builder{}
-	for i := lineNumberOfFirstHeader + 1; i < len(lines); i++ {
-		if headerRegex.MatchString(lines[i]) {
-			// It is a new diff header
-			// which means we have reached the next file's header
-
-			// capture content written to buffer so far as addition content
-			stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-			if stagedChanges != nil {
-				addition := NewAddition(additionFilename, stagedChanges)
-				result = append(
-					result, addition,
-				)
-			}
-
-			// get next file name and reset buffer for next iteration
-			matches := headerRegex.FindStringSubmatch(lines[i])
-			additionFilename = matches[1]
-			additionContentBuffer.Reset()
-		} else {
-			additionContentBuffer.WriteString(lines[i])
-			additionContentBuffer.WriteRune('\n')
+		if addedLines != nil && len(addedLines) == 0 {
+			continue
 		}
-	}
-
-	// Save last file's diff content
-	stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-	if stagedChanges != nil {
-		addition := NewAddition(additionFilename, stagedChanges)
+		addition := repo.blobAddition(change.path, nil, change.newBlob)
+		addition.AddedLines = addedLines
 		result = append(result, addition)
 	}
 
@@ -99,43 +100,200 @@ func (repo GitRepo) GetDiffForStagedFiles() []Addition {
 		"additions": result,
 	}).Debug("Generating staged additions.")
 
-	return result
+	return result, nil
 }
 
 //StagedAdditions returns the files staged for commit in a GitRepo
-func (repo GitRepo) StagedAdditions() []Addition {
-	files := repo.stagedFiles()
-	result := make([]Addition, len(files))
-	for i, file := range files {
-		data := repo.stagedVersionOfFile(file)
-		result[i] = NewAddition(file, data)
+func (repo GitRepo) StagedAdditions() ([]Addition, error) {
+	changes, err := repo.stagedChanges()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Addition, len(changes))
+	for i, change := range changes {
+		result[i] = repo.stagedAddition(change.path)
 	}
 
 	log.WithFields(log.Fields{
 		"additions": result,
 	}).Info("Generating staged additions.")
-	return result
+	return result, nil
 }
 
 //AllAdditions returns all the outgoing additions and modifications in a GitRepo. This does not include files that were deleted.
-func (repo GitRepo) AllAdditions() []Addition {
+func (repo GitRepo) AllAdditions() ([]Addition, error) {
 	return repo.AdditionsWithinRange("origin/master", "master")
 }
 
 //AdditionsWithinRange returns the outgoing additions and modifications in a GitRepo that are in the given commit range. This does not include files that were deleted.
-func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) []Addition {
-	files := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
+func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) ([]Addition, error) {
+	files, err := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
+	if err != nil {
+		return nil, err
+	}
 	result := make([]Addition, len(files))
 	for i, file := range files {
-		data, _ := repo.ReadRepoFile(file)
-		result[i] = NewAddition(file, data)
+		result[i] = NewLazyAddition(file, nil, repo.openRepoFile(file))
+		result[i].size = repo.repoFileSize(file)
 	}
 	log.WithFields(log.Fields{
 		"oldCommit": oldCommit,
 		"newCommit": newCommit,
 		"additions": result,
 	}).Info("Generating all additions in range.")
-	return result
+	return result, nil
+}
+
+//CommitAdditionsWithinRange returns the blobs that every commit in the range introduces, as additions that record the commit.
+//Unlike AdditionsWithinRange, content that one commit adds and a later one removes is returned as well,
+//and the contents are read from the committed blobs rather than the working tree.
+//A blob that several commits introduce at the same path is returned once, recording all of them.
+//Every commit reachable from newCommit is taken into account when oldCommit is empty.
+func (repo GitRepo) CommitAdditionsWithinRange(oldCommit string, newCommit string) ([]Addition, error) {
+	return repo.commitAdditions(revisionArgs(oldCommit, newCommit, false)...)
+}
+
+//UnpushedCommitAdditions is CommitAdditionsWithinRange, leaving out the commits that any remote-tracking ref reaches.
+//Those commits are already upstream, so there is no need to scan them again.
+func (repo GitRepo) UnpushedCommitAdditions(oldCommit string, newCommit string) ([]Addition, error) {
+	return repo.commitAdditions(revisionArgs(oldCommit, newCommit, true)...)
+}
+
+//RangeCommitAdditions is CommitAdditionsWithinRange for a revision range given the way git rev-list takes it, such as "origin/main..HEAD".
+//The range defaults to HEAD, and only the commits made after since are taken into account if it is not empty.
+func (repo GitRepo) RangeCommitAdditions(revisionRange string, since string) ([]Addition, error) {
+	return repo.commitAdditions(rangeArgs(revisionRange, since)...)
+}
+
+func (repo GitRepo) commitAdditions(revisions ...string) ([]Addition, error) {
+	args := append([]string{"rev-list", "--reverse"}, revisions...)
+	commits, err := repo.readRepoCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+	//--cc lists the files of a merge commit that differ from every parent, which are the ones the merge resolved, and --raw keeps them in the format below
+	changes, err := repo.readRepoCommandWithInput(commits, "git", "diff-tree", "--stdin", "-r", "-z", "--root", "--no-renames", "--cc", "--raw", "--diff-filter=ACM")
+	if err != nil {
+		return nil, err
+	}
+	var result []Addition
+	indexes := map[string]int{}
+	commit := ""
+	fields := splitNullTerminated(changes)
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], ":") {
+			commit = strings.TrimSpace(fields[i])
+			continue
+		}
+		//a change is described as ":<old mode> <new mode> <old blob> <new blob> <status>", followed by the path.
+		//A change of a merge commit has a colon, an old mode and an old blob for every parent.
+		parents := len(fields[i]) - len(strings.TrimLeft(fields[i], ":"))
+		change := strings.Fields(strings.TrimLeft(fields[i], ":"))
+		if i+1 >= len(fields) || len(change) < 2*parents+3 {
+			break
+		}
+		i++
+		path, mode, blob := fields[i], change[parents], change[2*parents+1]
+		if mode == submoduleMode {
+			continue
+		}
+		key := path + "\x00" + blob
+		if index, found := indexes[key]; found {
+			result[index].Commits = append(result[index].Commits, commit)
+			continue
+		}
+		addition := repo.blobAddition(path, []string{commit}, blob)
+		indexes[key] = len(result)
+		result = append(result, addition)
+	}
+	log.WithFields(log.Fields{
+		"revisions": revisions,
+		"additions": result,
+	}).Info("Generating additions of every commit in range.")
+	return result, nil
+}
+
+//CommitMessagesWithinRange returns an addition for every distinct message of the commits from oldCommit to newCommit, recording the commits that have it.
+//Every commit reachable from newCommit is taken into account when oldCommit is empty.
+func (repo GitRepo) CommitMessagesWithinRange(oldCommit string, newCommit string) ([]Addition, error) {
+	return repo.commitMessages(revisionArgs(oldCommit, newCommit, false)...)
+}
+
+//UnpushedCommitMessages is CommitMessagesWithinRange, leaving out the commits that any remote-tracking ref reaches
+func (repo GitRepo) UnpushedCommitMessages(oldCommit string, newCommit string) ([]Addition, error) {
+	return repo.commitMessages(revisionArgs(oldCommit, newCommit, true)...)
+}
+
+//RangeCommitMessages is CommitMessagesWithinRange for a revision range and date, as RangeCommitAdditions takes them
+func (repo GitRepo) RangeCommitMessages(revisionRange string, since string) ([]Addition, error) {
+	return repo.commitMessages(rangeArgs(revisionRange, since)...)
+}
+
+//AllCommitMessages returns an addition for every distinct message of the commits that any ref reaches, recording the commits that have it
+func (repo GitRepo) AllCommitMessages() ([]Addition, error) {
+	return repo.commitMessages("--all")
+}
+
+func (repo GitRepo) commitMessages(revisions ...string) ([]Addition, error) {
+	args := append([]string{"log", "--reverse", "--format=%H%x00%B%x00"}, revisions...)
+	output, err := repo.readRepoCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+	var result []Addition
+	indexes := map[string]int{}
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		commit, message := strings.TrimSpace(fields[i]), fields[i+1]
+		if index, found := indexes[message]; found {
+			result[index].Commits = append(result[index].Commits, commit)
+			continue
+		}
+		indexes[message] = len(result)
+		result = append(result, NewCommitMessageAddition([]byte(message), []string{commit}))
+	}
+	log.WithFields(log.Fields{
+		"revisions": revisions,
+		"additions": result,
+	}).Info("Generating additions of commit messages.")
+	return result, nil
+}
+
+//revisionRange returns the git range of the commits from oldCommit to newCommit, which is every commit reachable from newCommit when oldCommit is empty
+func revisionRange(oldCommit string, newCommit string) string {
+	if oldCommit == "" {
+		return newCommit
+	}
+	return oldCommit + ".." + newCommit
+}
+
+//revisionArgs returns the arguments that select the commits from oldCommit to newCommit, leaving out those that a remote-tracking ref reaches if unpushedOnly is set
+func revisionArgs(oldCommit string, newCommit string, unpushedOnly bool) []string {
+	args := []string{revisionRange(oldCommit, newCommit)}
+	if unpushedOnly {
+		args = append(args, "--not", "--remotes")
+	}
+	return args
+}
+
+//rangeArgs returns the arguments that select the commits of the revision range that were made after since, if it is not empty
+func rangeArgs(revisionRange string, since string) []string {
+	args := strings.Fields(revisionRange)
+	if len(args) == 0 {
+		args = []string{"HEAD"}
+	}
+	if since != "" {
+		args = append([]string{"--since=" + since}, args...)
+	}
+	return args
+}
+
+//NewCommitMessageAddition returns an Addition for a commit message, recording the commits that have it.
+//All commit messages share CommitMessagePath, so that .talismanrc can configure them like any file.
+func NewCommitMessageAddition(message []byte, commits []string) Addition {
+	addition := NewScannerAddition(CommitMessagePath, commits, message)
+	addition.CommitMessage = true
+	return addition
 }
 
 //NewAddition returns a new Addition for a file with supplied name and contents
@@ -157,6 +315,91 @@ func NewScannerAddition(filePath string, commits []string, content []byte) Addit
 	}
 }
 
+//NewLazyAddition returns a new Addition for a file with the supplied commits, whose contents are read through open only when they are needed
+func NewLazyAddition(filePath string, commits []string, open func() (io.ReadCloser, error)) Addition {
+	return Addition{
+		Path:    FilePath(filePath),
+		Name:    FileName(path.Base(filePath)),
+		Commits: commits,
+		open:    open,
+	}
+}
+
+//blobAddition returns an Addition whose contents are read from the git object with the supplied hash
+func (repo GitRepo) blobAddition(filePath string, commits []string, objectHash string) Addition {
+	addition := NewLazyAddition(filePath, commits, repo.blob(objectHash))
+	addition.Blob = objectHash
+	addition.size = repo.objectSize(objectHash)
+	return addition
+}
+
+//stagedAddition returns an Addition whose contents are the staged version of the file
+func (repo GitRepo) stagedAddition(filePath string) Addition {
+	addition := NewLazyAddition(filePath, nil, repo.stagedVersionOfFile(filePath))
+	addition.size = repo.objectSize(":" + filePath)
+	return addition
+}
+
+//Adds answers if the change being scanned adds or changes any of the lines from first to last
+func (a Addition) Adds(first, last int) bool {
+	if a.AddedLines == nil {
+		return true
+	}
+	for _, added := range a.AddedLines {
+		if added.First <= last && first <= added.Last {
+			return true
+		}
+	}
+	return false
+}
+
+//Reader returns a reader over the contents of the addition. The caller is expected to close it.
+func (a Addition) Reader() (io.ReadCloser, error) {
+	if a.open == nil {
+		return ioutil.NopCloser(bytes.NewReader(a.Data)), nil
+	}
+	return a.open()
+}
+
+//Content returns the contents of the addition.
+//Contents of lazily read additions are read every time they are needed, and released once the caller is done with them.
+func (a Addition) Content() ([]byte, error) {
+	if a.open == nil {
+		return a.Data, nil
+	}
+	return readAll(a.open)
+}
+
+//Size returns the size of the contents of the addition in bytes.
+//Additions read from git objects or the working tree are sized without reading their contents.
+func (a Addition) Size() (int64, error) {
+	if a.open == nil {
+		return int64(len(a.Data)), nil
+	}
+	if a.size != nil {
+		return a.size()
+	}
+	data, err := a.Content()
+	return int64(len(data)), err
+}
+
+func readAll(open func() (io.ReadCloser, error)) ([]byte, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	return data, err
+}
+
+//OpenBlob returns a reader over the contents of the git object with the supplied hash
+func (repo GitRepo) OpenBlob(objectHash string) (io.ReadCloser, error) {
+	return repo.openRepoCommand("git", "cat-file", "-p", objectHash)
+}
+
 //ReadRepoFile returns the contents of the supplied relative filename by locating it in the git repo
 func (repo GitRepo) ReadRepoFile(fileName string) ([]byte, error) {
 	path := filepath.Join(repo.root, fileName)
@@ -174,6 +417,18 @@ func (repo GitRepo) ReadRepoFileOrNothing(fileName string) ([]byte, error) {
 	return make([]byte, 0), nil
 }
 
+//WriteRepoFile replaces the contents of the supplied relative filename in the git repo, keeping its permissions.
+//A file that does not exist yet is created.
+func (repo GitRepo) WriteRepoFile(fileName string, contents []byte) error {
+	path := filepath.Join(repo.root, fileName)
+	log.Debugf("writing file %s", path)
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+	return ioutil.WriteFile(path, contents, mode)
+}
+
 //CheckIfFileExists checks if the file exists on the file system. Does not look into the file contents
 //Returns TRUE if file exists
 //Returns FALSE if the file is not found
@@ -185,19 +440,11 @@ func (repo GitRepo) CheckIfFileExists(fileName string) bool {
 	return true
 }
 
-//Matches states whether the addition matches the given pattern.
-//If the pattern ends in a path separator, then all files inside a directory with that name are matched. However, files with that name itself will not be matched.
-//If a pattern contains the path separator in any other location, the match works according to the pattern logic of the default golang glob mechanism
-//If there is no path separator anywhere in the pattern, the pattern is matched against the base name of the file. Thus, the pattern will match files with that name anywhere in the repository.
+//Matches states whether the addition matches the given gitignore-style pattern, as described by Pattern.
+//A negation on its own matches nothing, as there is nothing for it to take back.
 func (a Addition) Matches(pattern string) bool {
-	var result bool
-	if pattern[len(pattern)-1] == os.PathSeparator {
-		result = strings.HasPrefix(string(a.Path), pattern)
-	} else if strings.ContainsRune(pattern, os.PathSeparator) {
-		result, _ = path.Match(pattern, string(a.Path))
-	} else {
-		result, _ = path.Match(pattern, string(a.Name))
-	}
+	p := NewPattern(pattern)
+	result := !p.IsNegation() && p.Matches(a)
 	log.WithFields(log.Fields{
 		"pattern":  pattern,
 		"filePath": a.Path,
@@ -207,97 +454,227 @@ func (a Addition) Matches(pattern string) bool {
 }
 
 //TrackedFilesAsAdditions returns all of the tracked files in a GitRepo as Additions
-func (repo GitRepo) TrackedFilesAsAdditions() []Addition {
-	trackedFilePaths := repo.trackedFilePaths()
+func (repo GitRepo) TrackedFilesAsAdditions() ([]Addition, error) {
+	trackedFilePaths, err := repo.trackedFilePaths()
+	if err != nil {
+		return nil, err
+	}
 	var additions []Addition
 	for _, path := range trackedFilePaths {
 		additions = append(additions, NewAddition(path, make([]byte, 0)))
 	}
-	return additions
+	return additions, nil
 }
 
-func (repo GitRepo) trackedFilePaths() []string {
-	branchName := repo.currentBranch()
-	if len(branchName) == 0 {
-		return make([]string, 0)
+//IndexedFilePaths returns the paths of the files in the index of a GitRepo, which includes files that are staged for the first time
+func (repo GitRepo) IndexedFilePaths() ([]string, error) {
+	output, err := repo.executeRepoCommand("git", "ls-files", "-z")
+	if err != nil {
+		return nil, err
 	}
-	byteArray := repo.executeRepoCommand("git", "ls-tree", branchName, "--name-only", "-r")
-	trackedFilePaths := strings.Split(string(byteArray), "\n")
-	return trackedFilePaths
+	return splitNullTerminated(output), nil
+}
+
+//IndexedAdditions returns the files in the index of a GitRepo as additions, whose content is their staged version
+func (repo GitRepo) IndexedAdditions() ([]Addition, error) {
+	paths, err := repo.IndexedFilePaths()
+	if err != nil {
+		return nil, err
+	}
+	var additions []Addition
+	for _, path := range paths {
+		additions = append(additions, repo.stagedAddition(path))
+	}
+	return additions, nil
 }
 
-func (repo GitRepo) stagedFiles() []string {
-	stagedFiles := strings.Split(repo.fetchStagedChanges(), "\n")
+//FilesNamed returns the paths of the files in a GitRepo with the supplied base name, whether they are tracked or not.
+//Files that git is configured to ignore are left out.
+func (repo GitRepo) FilesNamed(name string) ([]string, error) {
+	output, err := repo.executeRepoCommand("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", ":(glob)**/"+name)
+	if err != nil {
+		return nil, err
+	}
+	return utility.UniqueItems(splitNullTerminated(output)), nil
+}
+
+func splitNullTerminated(output []byte) []string {
 	var result []string
-	for _, c := range stagedFiles {
-		if len(c) != 0 {
-			changeTypeAndFile := strings.Split(c, "\t")
-			if len(changeTypeAndFile) > 0 {
-				file := changeTypeAndFile[1]
-				result = append(result, file)
-			}
+	for _, file := range strings.Split(string(output), "\x00") {
+		if len(file) != 0 {
+			result = append(result, file)
 		}
 	}
 	return result
 }
 
-func (repo GitRepo) currentBranch() string {
-	if !repo.hasBranch() {
-		return ""
+func (repo GitRepo) trackedFilePaths() ([]string, error) {
+	branchName, err := repo.currentBranch()
+	if err != nil || len(branchName) == 0 {
+		return make([]string, 0), err
+	}
+	byteArray, err := repo.executeRepoCommand("git", "ls-tree", branchName, "--name-only", "-r")
+	if err != nil {
+		return nil, err
+	}
+	trackedFilePaths := strings.Split(string(byteArray), "\n")
+	return trackedFilePaths, nil
+}
+
+//stagedChange is a file that is added, copied, modified, renamed or changed in type by the staged changes, as git diff --raw describes it
+type stagedChange struct {
+	status           byte
+	oldBlob, newBlob string
+	oldPath, path    string
+}
+
+//isNewPath answers if the change creates the path of the file, rather than changing a file at that path
+func (c stagedChange) isNewPath() bool {
+	return c.status == 'A' || c.status == 'C' || c.status == 'R'
+}
+
+//stagedChanges lists the staged changes from NUL-delimited plumbing output, which handles any path.
+//Changes to submodules are left out, as they have no content to scan.
+func (repo GitRepo) stagedChanges() ([]stagedChange, error) {
+	output, err := repo.readRepoCommand("git", "diff", "--cached", "--raw", "-z", "--no-abbrev", "--find-renames", "--find-copies", "--diff-filter=ACMRT")
+	if err != nil {
+		return nil, err
+	}
+	var result []stagedChange
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i < len(fields); i++ {
+		//a change is described as ":<old mode> <new mode> <old blob> <new blob> <status><score>", followed by its paths
+		change := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if !strings.HasPrefix(fields[i], ":") || len(change) < 5 || i+1 >= len(fields) {
+			continue
+		}
+		c := stagedChange{status: change[4][0], oldBlob: change[2], newBlob: change[3], oldPath: fields[i+1], path: fields[i+1]}
+		i++
+		if (c.status == 'R' || c.status == 'C') && i+1 < len(fields) {
+			i++
+			c.path = fields[i]
+		}
+		if change[1] == submoduleMode {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+//addedLines returns the lines of the staged file that the change adds or changes, read from the headers of its hunks.
+//It returns nil when every line is added, as they are in a binary file, or at a new path.
+//The lines of a renamed or copied file are all added, as the ignores of its old path no longer cover them.
+func (repo GitRepo) addedLines(change stagedChange) ([]LineRange, error) {
+	if change.isNewPath() {
+		return nil, nil
+	}
+	patch, err := repo.readRepoCommand("git", "diff", "-U0", "--no-color", "--no-ext-diff", "--no-textconv", change.oldBlob, change.newBlob)
+	if err != nil {
+		return nil, err
+	}
+	if binaryPatch.Match(patch) {
+		return nil, nil
+	}
+	result := []LineRange{}
+	for _, header := range hunkHeader.FindAllSubmatch(patch, -1) {
+		first, _ := strconv.Atoi(string(header[1]))
+		count := 1
+		if len(header[2]) > 0 {
+			count, _ = strconv.Atoi(string(header[2]))
+		}
+		if count > 0 {
+			result = append(result, LineRange{First: first, Last: first + count - 1})
+		}
+	}
+	return result, nil
+}
+
+//currentBranch returns the name of the branch that HEAD is on, which is empty in a repository without branches
+func (repo GitRepo) currentBranch() (string, error) {
+	if hasBranch, err := repo.hasBranch(); err != nil || !hasBranch {
+		return "", err
+	}
+	byteArray, err := repo.readRepoCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
 	}
-	byteArray := repo.executeRepoCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
 	branchName := strings.TrimSpace(string(byteArray))
-	return branchName
+	return branchName, nil
 }
 
-func (repo GitRepo) hasBranch() bool {
-	byteArray := repo.executeRepoCommand("git", "branch")
-	if len(string(byteArray)) != 0 {
-		return true
+func (repo GitRepo) hasBranch() (bool, error) {
+	byteArray, err := repo.executeRepoCommand("git", "branch")
+	if err != nil {
+		return false, err
 	}
-	return false
+	return len(string(byteArray)) != 0, nil
 }
 
-func (repo GitRepo) stagedVersionOfFile(file string) []byte {
-	return repo.executeRepoCommand("git", "show", ":"+file)
+func (repo GitRepo) stagedVersionOfFile(file string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return repo.openRepoCommand("git", "show", ":"+file)
+	}
 }
 
-func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) []string {
-	allChanges := strings.Split(repo.fetchRawOutgoingDiff(oldCommit, newCommit), "\n")
-	var result []string
-	for _, c := range allChanges {
-		if len(c) != 0 {
-			result = append(result, c)
+func (repo GitRepo) blob(objectHash string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return repo.OpenBlob(objectHash)
+	}
+}
+
+//objectSize returns a function that answers the size of the git object named by the supplied revision, without reading it
+func (repo GitRepo) objectSize(revision string) func() (int64, error) {
+	return func() (int64, error) {
+		output, err := repo.readRepoCommand("git", "cat-file", "-s", revision)
+		if err != nil {
+			return 0, err
 		}
+		return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
 	}
-	return result
 }
 
-func (repo *GitRepo) fetchStagedChanges() string {
-	return string(repo.executeRepoCommand("git", "diff", "--cached", "--name-status", "--diff-filter=ACM"))
+func (repo GitRepo) repoFileSize(fileName string) func() (int64, error) {
+	return func() (int64, error) {
+		info, err := os.Stat(filepath.Join(repo.root, fileName))
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
 }
 
-// extractAdditions will accept git diff --staged {file} output and filters the command output
-// to get only the modified sections of the file
-func (repo *GitRepo) extractAdditions(diffContent string) []byte {
-	var result []byte
-	changes := strings.Split(diffContent, "\n")
-	for _, c := range changes {
-		if !strings.HasPrefix(c, "+++") && !strings.HasPrefix(c, "---") && strings.HasPrefix(c, "+") {
+func (repo GitRepo) openRepoFile(fileName string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		path := filepath.Join(repo.root, fileName)
+		log.Debugf("opening file %s", path)
+		return os.Open(path)
+	}
+}
 
-			result = append(result, strings.TrimPrefix(c, "+")...)
-			result = append(result, "\n"...)
+func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) ([]string, error) {
+	rawOutgoingDiff, err := repo.fetchRawOutgoingDiff(oldCommit, newCommit)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, c := range strings.Split(rawOutgoingDiff, "\n") {
+		if len(c) != 0 {
+			result = append(result, c)
 		}
 	}
-	return result
+	return result, nil
 }
 
-func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) string {
+func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) (string, error) {
 	gitRange := oldCommit + ".." + newCommit
-	return string(repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM"))
+	rawOutgoingDiff, err := repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM")
+	return string(rawOutgoingDiff), err
 }
 
-func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byte {
+//executeRepoCommand runs the command in the repository and returns its combined output.
+//Failures are logged and returned to the caller, rather than ending the process.
+func (repo GitRepo) executeRepoCommand(commandName string, args ...string) ([]byte, error) {
 	log.WithFields(log.Fields{
 		"command": commandName,
 		"args":    args,
@@ -311,10 +688,67 @@ func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byt
 		"output":  string(co),
 		"error":   err,
 	})
-	if err == nil {
-		logEntry.Debug("Git command excuted successfully")
-	} else {
-		logEntry.Fatal("Git command execution failed")
+	if err != nil {
+		logEntry.Error("Git command execution failed")
+		return co, fmt.Errorf("%s %s failed: %v: %s", commandName, strings.Join(args, " "), err, strings.TrimSpace(string(co)))
+	}
+	logEntry.Debug("Git command excuted successfully")
+	return co, nil
+}
+
+//commandReader streams the output of a running command, and waits for the command to exit once it is closed
+type commandReader struct {
+	io.ReadCloser
+	command *exec.Cmd
+	stderr  *bytes.Buffer
+}
+
+func (r commandReader) Close() error {
+	r.ReadCloser.Close()
+	if err := r.command.Wait(); err != nil {
+		return fmt.Errorf("%s %s failed: %v: %s", r.command.Path, strings.Join(r.command.Args[1:], " "), err, r.stderr.String())
+	}
+	return nil
+}
+
+//readRepoCommand runs the command in the repository and returns its standard output, which unlike executeRepoCommand leaves out what it writes to standard error
+func (repo GitRepo) readRepoCommand(commandName string, args ...string) ([]byte, error) {
+	return repo.readRepoCommandWithInput(nil, commandName, args...)
+}
+
+//readRepoCommandWithInput is readRepoCommand for a command that reads the supplied standard input
+func (repo GitRepo) readRepoCommandWithInput(input []byte, commandName string, args ...string) ([]byte, error) {
+	reader, err := repo.openRepoCommandWithInput(input, commandName, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := ioutil.ReadAll(reader)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	return output, err
+}
+
+func (repo GitRepo) openRepoCommand(commandName string, args ...string) (io.ReadCloser, error) {
+	return repo.openRepoCommandWithInput(nil, commandName, args...)
+}
+
+func (repo GitRepo) openRepoCommandWithInput(input []byte, commandName string, args ...string) (io.ReadCloser, error) {
+	log.WithFields(log.Fields{
+		"command": commandName,
+		"args":    args,
+	}).Debug("Building streaming repo command")
+	command := exec.Command(commandName, args...)
+	command.Dir = repo.root
+	command.Stdin = bytes.NewReader(input)
+	stderr := &bytes.Buffer{}
+	command.Stderr = stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := command.Start(); err != nil {
+		return nil, err
 	}
-	return co
+	return commandReader{stdout, command, stderr}, nil
 }
@@ -1,11 +1,10 @@
 package gitrepo
 
 import (
-	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -28,47 +27,84 @@ func init() {
 func TestEmptyRepoReturnsNoFileChanges(t *testing.T) {
 	cleanTestData()
 	_, repo := setupOriginAndClones(testLocation, cloneLocation)
-	assert.Len(t, repo.AllAdditions(), 0, "Empty git repo should not have any changes")
+	assert.Len(t, listed(t)(repo.AllAdditions()), 0, "Empty git repo should not have any changes")
 }
 
 func TestGetDiffForStagedFiles(t *testing.T) {
 	cleanTestData()
 	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	linesBefore := strings.Count(string(git.FileContents("a.txt")), "\n")
 	git.AppendFileContent("a.txt", "New content.\n", "Spanning multiple lines, even.")
 	git.CreateFileWithContents("new.txt", "created contents")
 	git.Add("a.txt")
 	git.Add("new.txt")
-	additions := repo.GetDiffForStagedFiles()
+	additions := listed(t)(repo.GetDiffForStagedFiles())
 
 	if assert.Len(t, additions, 2) {
 		modifiedAddition := additions[0]
 		createdAddition := additions[1]
 
-		aTxtFileContents, err := ioutil.ReadFile(path.Join(cloneLocation, "a.txt"))
-		assert.NoError(t, err)
-		newTxtFileContents, err := ioutil.ReadFile(path.Join(cloneLocation, "new.txt"))
-		assert.NoError(t, err)
+		assert.Equal(t, FilePath("a.txt"), modifiedAddition.Path)
+		assert.Equal(t, string(git.FileContents("a.txt")), contentOf(t, modifiedAddition))
+		assert.Equal(t, []LineRange{{First: linesBefore + 1, Last: linesBefore + 2}}, modifiedAddition.AddedLines)
 
-		expectedModifiedAddition := Addition{
-			Path: FilePath("a.txt"),
-			Name: FileName("a.txt"),
-			Data: []byte(fmt.Sprintf("%s\n", string(aTxtFileContents))),
-		}
+		assert.Equal(t, FilePath("new.txt"), createdAddition.Path)
+		assert.Equal(t, "created contents", contentOf(t, createdAddition))
+		assert.Nil(t, createdAddition.AddedLines, "Every line of a new file is added")
+	}
+}
 
-		expectedCreatedAddition := Addition{
-			Path: FilePath("new.txt"),
-			Name: FileName("new.txt"),
-			Data: []byte(fmt.Sprintf("%s\n", string(newTxtFileContents))),
-		}
+func TestGetDiffForStagedFilesLeavesOutFilesThatOnlyLoseLines(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.OverwriteFileContent("a.txt", "")
+	git.Add("a.txt")
+
+	assert.Len(t, listed(t)(repo.GetDiffForStagedFiles()), 0)
+}
+
+func TestGetDiffForStagedFilesHandlesPathsWithSpacesAndQuotes(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("dir with spaces/\"quoted\" file.txt", "some contents\n")
+	git.Add(".")
+
+	additions := listed(t)(repo.GetDiffForStagedFiles())
+
+	if assert.Len(t, additions, 1) {
+		assert.Equal(t, FilePath("dir with spaces/\"quoted\" file.txt"), additions[0].Path)
+		assert.Equal(t, "some contents\n", contentOf(t, additions[0]))
+	}
+}
+
+func TestGetDiffForStagedFilesNamesRenamedFilesByTheirNewPath(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.ExecCommand("git", "mv", "a.txt", "private.pem")
 
-		// For human-readable comparison
-		assert.Equal(t, string(expectedModifiedAddition.Data), string(modifiedAddition.Data))
-		assert.Equal(t, string(expectedCreatedAddition.Data), string(createdAddition.Data))
+	additions := listed(t)(repo.GetDiffForStagedFiles())
 
-		assert.Equal(t, expectedModifiedAddition, modifiedAddition)
-		assert.Equal(t, expectedCreatedAddition, createdAddition)
+	if assert.Len(t, additions, 1) {
+		assert.Equal(t, FilePath("private.pem"), additions[0].Path)
+		assert.Nil(t, additions[0].AddedLines, "Expected every line of a renamed file to be scanned at its new path")
 	}
+}
+
+func TestGetDiffForStagedFilesTakesEveryLineOfBinaryFilesToBeAdded(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	exec.Command("cp", "./pixel.jpg", repo.root).Run()
+	git.Add("pixel.jpg")
+	pixel, err := ioutil.ReadFile("pixel.jpg")
+	assert.NoError(t, err)
+
+	additions := listed(t)(repo.GetDiffForStagedFiles())
 
+	if assert.Len(t, additions, 1) {
+		assert.Equal(t, FileName("pixel.jpg"), additions[0].Name)
+		assert.Equal(t, string(pixel), contentOf(t, additions[0]))
+		assert.Nil(t, additions[0].AddedLines)
+	}
 }
 
 func TestAdditionsReturnsEditsAndAdds(t *testing.T) {
@@ -78,9 +114,120 @@ func TestAdditionsReturnsEditsAndAdds(t *testing.T) {
 	git.CreateFileWithContents("new.txt", "created contents")
 	git.AddAndcommit("*", "added to lorem-ipsum content with my own stuff!")
 
-	additions := repo.AdditionsWithinRange("HEAD~1", "HEAD")
+	additions := listed(t)(repo.AdditionsWithinRange("HEAD~1", "HEAD"))
 	assert.Len(t, additions, 2)
-	assert.True(t, strings.HasSuffix(string(additions[0].Data), "New content.\nSpanning multiple lines, even."))
+	assert.True(t, strings.HasSuffix(contentOf(t, additions[0]), "New content.\nSpanning multiple lines, even."))
+}
+
+func TestCommitAdditionsReturnTheBlobsOfEveryCommit(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "first version")
+	git.AddAndcommit("*", "add new.txt")
+	firstCommit := git.LatestCommit()
+	git.OverwriteFileContent("new.txt", "second version")
+	git.AddAndcommit("*", "change new.txt")
+	git.OverwriteFileContent("new.txt", "working tree version")
+
+	additions := listed(t)(repo.CommitAdditionsWithinRange("HEAD~2", "HEAD"))
+	if assert.Len(t, additions, 2) {
+		assert.Equal(t, "first version", contentOf(t, additions[0]))
+		assert.Equal(t, []string{firstCommit}, additions[0].Commits)
+		assert.Equal(t, "second version", contentOf(t, additions[1]))
+		assert.Equal(t, []string{git.LatestCommit()}, additions[1].Commits)
+	}
+}
+
+func TestCommitAdditionsReturnTheFilesThatAMergeResolved(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("shared.txt", "base version")
+	git.AddAndcommit("*", "add shared.txt")
+	git.ExecCommand("git", "checkout", "-b", "feature")
+	git.CreateFileWithContents("feature.txt", "feature version")
+	git.AddAndcommit("*", "add feature.txt")
+	git.ExecCommand("git", "checkout", "-")
+	git.CreateFileWithContents("main.txt", "main version")
+	git.AddAndcommit("*", "add main.txt")
+	git.ExecCommand("git", "merge", "--no-ff", "--no-commit", "feature")
+	git.OverwriteFileContent("shared.txt", "resolved by the merge")
+	git.Add("shared.txt")
+	git.ExecCommand("git", "commit", "-m", "merge feature")
+
+	additions := listed(t)(repo.CommitAdditionsWithinRange("HEAD^1", "HEAD"))
+	if assert.Len(t, additions, 2) {
+		assert.Equal(t, FilePath("feature.txt"), additions[0].Path)
+		assert.Equal(t, FilePath("shared.txt"), additions[1].Path)
+		assert.Equal(t, "resolved by the merge", contentOf(t, additions[1]))
+		assert.Equal(t, []string{git.LatestCommit()}, additions[1].Commits)
+	}
+}
+
+func TestUnpushedCommitAdditionsLeaveOutCommitsThatAreOnARemote(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "new contents")
+	git.AddAndcommit("*", "add new.txt")
+
+	additions := listed(t)(repo.UnpushedCommitAdditions("", "HEAD"))
+	if assert.Len(t, additions, 1) {
+		assert.Equal(t, FilePath("new.txt"), additions[0].Path)
+	}
+	assert.Len(t, listed(t)(repo.CommitAdditionsWithinRange("", "HEAD")), 3, "Expected the files that are already on origin to be scanned as well")
+}
+
+func TestRangeCommitAdditionsReturnTheBlobsOfTheCommitsOfTheRange(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "first version")
+	git.AddAndcommit("*", "add new.txt")
+	git.OverwriteFileContent("new.txt", "second version")
+	git.AddAndcommit("*", "change new.txt")
+
+	additions := listed(t)(repo.RangeCommitAdditions("HEAD~1..HEAD", ""))
+	if assert.Len(t, additions, 1) {
+		assert.Equal(t, "second version", contentOf(t, additions[0]))
+		assert.Equal(t, []string{git.LatestCommit()}, additions[0].Commits)
+	}
+}
+
+func TestRangeArgsSelectTheCommitsOfTheRangeSinceTheDate(t *testing.T) {
+	assert.Equal(t, []string{"origin/main..HEAD"}, rangeArgs("origin/main..HEAD", ""))
+	assert.Equal(t, []string{"--since=2 weeks ago", "HEAD"}, rangeArgs("", "2 weeks ago"))
+	assert.Equal(t, []string{"--since=2024-01-31", "main", "^release"}, rangeArgs("main ^release", "2024-01-31"))
+}
+
+func TestRangeCommitAdditionsReportAnUnknownRange(t *testing.T) {
+	cleanTestData()
+	_, repo := setupOriginAndClones(testLocation, cloneLocation)
+
+	additions, err := repo.RangeCommitAdditions("no-such-ref..HEAD", "")
+
+	assert.Error(t, err)
+	assert.Empty(t, additions)
+}
+
+func TestCommitMessagesWithinRangeRecordTheCommitsThatHaveThem(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "first version")
+	git.AddAndcommit("*", "wip")
+	firstCommit := git.LatestCommit()
+	git.OverwriteFileContent("new.txt", "second version")
+	git.AddAndcommit("*", "wip")
+	secondCommit := git.LatestCommit()
+	git.OverwriteFileContent("new.txt", "third version")
+	git.AddAndcommit("*", "finish new.txt")
+
+	additions := listed(t)(repo.CommitMessagesWithinRange("HEAD~3", "HEAD"))
+	if assert.Len(t, additions, 2) {
+		assert.Equal(t, FilePath(CommitMessagePath), additions[0].Path)
+		assert.Equal(t, "wip\n", contentOf(t, additions[0]))
+		assert.Equal(t, []string{firstCommit, secondCommit}, additions[0].Commits)
+		assert.Equal(t, "finish new.txt\n", contentOf(t, additions[1]))
+		assert.Equal(t, []string{git.LatestCommit()}, additions[1].Commits)
+	}
+	assert.Len(t, listed(t)(repo.UnpushedCommitMessages("", "HEAD")), 2, "Expected the messages of the commits on origin to be left out")
 }
 
 func TestNewlyAddedFilesAreCountedAsChanges(t *testing.T) {
@@ -89,7 +236,7 @@ func TestNewlyAddedFilesAreCountedAsChanges(t *testing.T) {
 	git.CreateFileWithContents("h", "Hello")
 	git.CreateFileWithContents("foo/bar/w", ", World!")
 	git.AddAndcommit("*", "added hello world")
-	assert.Len(t, repo.AllAdditions(), 2)
+	assert.Len(t, listed(t)(repo.AllAdditions()), 2)
 }
 
 func TestOutgoingContentOfNewlyAddedFilesIsAvailableInChanges(t *testing.T) {
@@ -98,8 +245,8 @@ func TestOutgoingContentOfNewlyAddedFilesIsAvailableInChanges(t *testing.T) {
 	git.CreateFileWithContents("foo/bar/w", "new contents")
 	git.AddAndcommit("*", "added new files")
 
-	assert.Len(t, repo.AllAdditions(), 1)
-	assert.True(t, strings.HasSuffix(string(repo.AllAdditions()[0].Data), "new contents"))
+	assert.Len(t, listed(t)(repo.AllAdditions()), 1)
+	assert.True(t, strings.HasSuffix(contentOf(t, listed(t)(repo.AllAdditions())[0]), "new contents"))
 }
 
 func TestOutgoingContentOfModifiedFilesIsAvailableInChanges(t *testing.T) {
@@ -107,8 +254,8 @@ func TestOutgoingContentOfModifiedFilesIsAvailableInChanges(t *testing.T) {
 	git, repo := setupOriginAndClones(testLocation, cloneLocation)
 	git.AppendFileContent("a.txt", "New content.\n", "Spanning multiple lines, even.")
 	git.AddAndcommit("a.txt", "added to lorem-ipsum content with my own stuff!")
-	assert.Len(t, repo.AllAdditions(), 1)
-	assert.True(t, strings.HasSuffix(string(repo.AllAdditions()[0].Data), "New content.\nSpanning multiple lines, even."))
+	assert.Len(t, listed(t)(repo.AllAdditions()), 1)
+	assert.True(t, strings.HasSuffix(contentOf(t, listed(t)(repo.AllAdditions())[0]), "New content.\nSpanning multiple lines, even."))
 }
 
 func TestMultipleOutgoingChangesToTheSameFileAreAvailableInAdditions(t *testing.T) {
@@ -120,8 +267,8 @@ func TestMultipleOutgoingChangesToTheSameFileAreAvailableInAdditions(t *testing.
 	git.AppendFileContent("a.txt", "More new content.\n")
 	git.AddAndcommit("a.txt", "added some more new content")
 
-	assert.Len(t, repo.AllAdditions(), 1)
-	assert.True(t, strings.HasSuffix(string(repo.AllAdditions()[0].Data), "New content.\nMore new content.\n"))
+	assert.Len(t, listed(t)(repo.AllAdditions()), 1)
+	assert.True(t, strings.HasSuffix(contentOf(t, listed(t)(repo.AllAdditions())[0]), "New content.\nMore new content.\n"))
 }
 
 func TestContentOfDeletedFilesIsNotAvailableInChanges(t *testing.T) {
@@ -129,7 +276,7 @@ func TestContentOfDeletedFilesIsNotAvailableInChanges(t *testing.T) {
 	git, repo := setupOriginAndClones(testLocation, cloneLocation)
 	git.RemoveFile("a.txt")
 	git.AddAndcommit("a.txt", "Deleted this file. After all, it only had lorem-ipsum content.")
-	assert.Equal(t, 0, len(repo.AllAdditions()), "There should be no additions because there only an outgoing deletion")
+	assert.Equal(t, 0, len(listed(t)(repo.AllAdditions())), "There should be no additions because there only an outgoing deletion")
 }
 
 func TestDiffContainingBinaryFileChangesDoesNotBlowUp(t *testing.T) {
@@ -137,8 +284,8 @@ func TestDiffContainingBinaryFileChangesDoesNotBlowUp(t *testing.T) {
 	git, repo := setupOriginAndClones(testLocation, cloneLocation)
 	exec.Command("cp", "./pixel.jpg", repo.root).Run()
 	git.AddAndcommit("pixel.jpg", "Testing binary diff.")
-	assert.Len(t, repo.AllAdditions(), 1)
-	assert.Equal(t, "pixel.jpg", string(repo.AllAdditions()[0].Name))
+	assert.Len(t, listed(t)(repo.AllAdditions()), 1)
+	assert.Equal(t, "pixel.jpg", string(listed(t)(repo.AllAdditions())[0].Name))
 }
 
 func TestStagedAdditionsIncludeStagedFiles(t *testing.T) {
@@ -150,10 +297,29 @@ func TestStagedAdditionsIncludeStagedFiles(t *testing.T) {
 	git.AppendFileContent("a.txt", "More new content\n")
 	git.AppendFileContent("alice/bob/b.txt", "New content to b\n")
 
-	stagedAdditions := repo.StagedAdditions()
+	stagedAdditions := listed(t)(repo.StagedAdditions())
 	assert.Len(t, stagedAdditions, 1)
 	assert.Equal(t, "a.txt", string(stagedAdditions[0].Name))
-	assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
+	assert.Equal(t, "New content.\n", contentOf(t, stagedAdditions[0]))
+}
+
+func TestIndexedAdditionsHaveTheStagedContent(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.OverwriteFileContent("a.txt", "Staged content.\n")
+	git.Add("a.txt")
+	git.OverwriteFileContent("a.txt", "Working tree content.\n")
+
+	indexedAdditions, err := repo.IndexedAdditions()
+
+	assert.NoError(t, err)
+	for _, addition := range indexedAdditions {
+		if addition.Path == "a.txt" {
+			assert.Equal(t, "Staged content.\n", contentOf(t, addition))
+			return
+		}
+	}
+	t.Errorf("Expected a.txt to be in the index")
 }
 
 func TestStagedAdditionsIncludeStagedNewFiles(t *testing.T) {
@@ -162,10 +328,10 @@ func TestStagedAdditionsIncludeStagedNewFiles(t *testing.T) {
 	git.CreateFileWithContents("new.txt", "New content.\n")
 	git.Add("new.txt")
 
-	stagedAdditions := repo.StagedAdditions()
+	stagedAdditions := listed(t)(repo.StagedAdditions())
 	assert.Len(t, stagedAdditions, 1)
 	assert.Equal(t, "new.txt", string(stagedAdditions[0].Name))
-	assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
+	assert.Equal(t, "New content.\n", contentOf(t, stagedAdditions[0]))
 }
 
 func TestStagedAdditionsShouldNotIncludeDeletedFiles(t *testing.T) {
@@ -174,10 +340,20 @@ func TestStagedAdditionsShouldNotIncludeDeletedFiles(t *testing.T) {
 	git.RemoveFile("a.txt")
 	git.Add(".")
 
-	stagedAdditions := repo.StagedAdditions()
+	stagedAdditions := listed(t)(repo.StagedAdditions())
 	assert.Len(t, stagedAdditions, 0)
 }
 
+func TestAdditionsAddTheLinesOfTheirAddedRanges(t *testing.T) {
+	addition := Addition{AddedLines: []LineRange{{First: 3, Last: 4}}}
+
+	assert.True(t, addition.Adds(4, 6))
+	assert.True(t, addition.Adds(1, 3))
+	assert.False(t, addition.Adds(1, 2))
+	assert.False(t, addition.Adds(5, 5))
+	assert.True(t, Addition{}.Adds(1, 1), "Every line is added when no ranges are known")
+}
+
 func TestMatchShouldAllowWildcardPatternMatches(t *testing.T) {
 	file1 := Addition{Path: "bigfile", Name: "bigfile"}
 	file2 := Addition{Path: "anotherbigfile", Name: "anotherbigfile"}
@@ -197,3 +373,78 @@ func setupOriginAndClones(originLocation, cloneLocation string) (*git_testing.Gi
 	gitClone := git.GitClone(filepath.Join(cwd, cloneLocation))
 	return gitClone, RepoLocatedAt(cloneLocation)
 }
+
+func TestStagedAdditionsAreReadOnlyWhenTheirContentIsNeeded(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "New content.\n")
+	git.Add("new.txt")
+
+	stagedAdditions := listed(t)(repo.StagedAdditions())
+	assert.Len(t, stagedAdditions, 1)
+	assert.Nil(t, stagedAdditions[0].Data)
+	size, err := stagedAdditions[0].Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("New content.\n")), size)
+}
+
+func TestLazyAdditionsDoNotKeepTheirContentsAfterUse(t *testing.T) {
+	opened := 0
+	addition := NewLazyAddition("secret.txt", nil, func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader("password=UnsafePassword")), nil
+	})
+
+	assert.Equal(t, "password=UnsafePassword", contentOf(t, addition))
+	assert.Nil(t, addition.Data, "Expected the contents not to be kept on the addition")
+	assert.Equal(t, "password=UnsafePassword", contentOf(t, addition))
+	assert.Equal(t, 2, opened, "Expected the contents to be read again, as they are not kept")
+}
+
+func TestCommitAdditionsAreSizedWithoutReadingThem(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "New content.\n")
+	git.AddAndcommit("new.txt", "add new.txt")
+
+	additions := listed(t)(repo.CommitAdditionsWithinRange("origin/master", "master"))
+
+	if assert.Len(t, additions, 1) {
+		opened := 0
+		open := additions[0].open
+		additions[0].open = func() (io.ReadCloser, error) {
+			opened++
+			return open()
+		}
+		size, err := additions[0].Size()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len("New content.\n")), size)
+		assert.Equal(t, 0, opened, "Expected the blob to be sized without reading it")
+	}
+}
+
+//listed asserts that listing additions did not fail, and returns them
+func listed(t *testing.T) func([]Addition, error) []Addition {
+	return func(additions []Addition, err error) []Addition {
+		assert.NoError(t, err)
+		return additions
+	}
+}
+
+func contentOf(t *testing.T, addition Addition) string {
+	content, err := addition.Content()
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestAdditionsWithinAnUnknownRangeReportTheGitError(t *testing.T) {
+	cleanTestData()
+	_, repo := setupOriginAndClones(testLocation, cloneLocation)
+
+	additions, err := repo.AdditionsWithinRange("no-such-ref", "HEAD")
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "git diff no-such-ref..HEAD")
+	}
+	assert.Empty(t, additions)
+}
@@ -0,0 +1,74 @@
+package gitrepo
+
+import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+//Pattern is a file pattern with the semantics of a line of a .gitignore file:
+//
+//A pattern that starts with ! is a negation, which takes the files it matches back out of the earlier patterns of a list.
+//A pattern that ends in a slash only matches directories, that is the files inside them.
+//A pattern that contains a slash anywhere else is anchored to the root, otherwise it matches at any depth.
+//A * matches anything but a slash, while ** matches any number of directories.
+//A pattern that matches a directory matches every file inside it.
+type Pattern struct {
+	glob          string
+	negation      bool
+	directoryOnly bool
+}
+
+//NewPattern parses a gitignore-style pattern. A leading ! that is escaped with a backslash is part of the pattern.
+func NewPattern(pattern string) Pattern {
+	p := Pattern{}
+	if strings.HasPrefix(pattern, `\!`) {
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "!") {
+		p.negation = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		p.directoryOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	p.glob = strings.TrimPrefix(pattern, "/")
+	return p
+}
+
+//IsNegation answers if the pattern starts with !
+func (p Pattern) IsNegation() bool {
+	return p.negation
+}
+
+//Matches answers if the pattern matches the addition, or one of the directories it lies in, whether or not the pattern is a negation
+func (p Pattern) Matches(a Addition) bool {
+	if p.glob == "" || p.glob == "**/" {
+		return false
+	}
+	segments := strings.Split(string(a.Path), "/")
+	for i := 1; i <= len(segments); i++ {
+		if i == len(segments) && p.directoryOnly {
+			break
+		}
+		if matched, _ := doublestar.Match(p.glob, strings.Join(segments[:i], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//MatchesPatterns answers if the addition is matched by the list of patterns.
+//Like in a .gitignore file, the last pattern that matches the addition decides, so that a negation can take it back out.
+func (a Addition) MatchesPatterns(patterns []string) bool {
+	result := false
+	for _, pattern := range patterns {
+		if p := NewPattern(pattern); p.Matches(a) {
+			result = !p.IsNegation()
+		}
+	}
+	return result
+}
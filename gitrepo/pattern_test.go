@@ -0,0 +1,44 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternsFollowGitignoreSemantics(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"*.pem", "keys/private.pem", true},
+		{"private.pem", "keys/private.pem", true},
+		{"/private.pem", "keys/private.pem", false},
+		{"/private.pem", "private.pem", true},
+		{"keys/*.pem", "keys/private.pem", true},
+		{"keys/*.pem", "old/keys/private.pem", false},
+		{"src/**/fixtures/*.json", "src/fixtures/user.json", true},
+		{"src/**/fixtures/*.json", "src/api/v1/fixtures/user.json", true},
+		{"src/**/fixtures/*.json", "src/api/v1/fixtures/nested/user.json", false},
+		{"**/fixtures/", "test/fixtures/user.json", true},
+		{"fixtures/", "test/fixtures/user.json", true},
+		{"fixtures/", "test/fixtures", false},
+		{"fixtures", "test/fixtures/user.json", true},
+		{"vendor/**", "vendor/github.com/lib/lib.go", true},
+		{"!*.pem", "private.pem", false},
+		{`\!important.txt`, "docs/!important.txt", true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.matches, NewAddition(c.path, nil).Matches(c.pattern), "Expected %s matching %s to be %v", c.pattern, c.path, c.matches)
+	}
+}
+
+func TestTheLastMatchingPatternDecides(t *testing.T) {
+	patterns := []string{"fixtures/", "!fixtures/real/", "fixtures/real/fake.pem"}
+
+	assert.True(t, NewAddition("fixtures/user.json", nil).MatchesPatterns(patterns))
+	assert.False(t, NewAddition("fixtures/real/key.pem", nil).MatchesPatterns(patterns))
+	assert.True(t, NewAddition("fixtures/real/fake.pem", nil).MatchesPatterns(patterns))
+	assert.False(t, NewAddition("src/main.go", nil).MatchesPatterns(patterns))
+}
@@ -1,6 +1,5 @@
 #!/bin/bash
 shopt -s extglob
-exec < /dev/tty
 
 # set TALISMAN_DEBUG="some-non-empty-value" in the env to get verbose output when the hook or talisman is running
 function echo_debug() {
@@ -31,12 +30,16 @@ NAME=$(basename $0)
 ORG_REPO=${ORG_REPO:-'thoughtworks/talisman'}
 
 # given the various symlinks, this script may be invoked as
-#     'pre-commit', 'pre-push', 'talisman_hook_script pre-commit' or 'talisman_hook_script pre-push'
+#     'pre-commit', 'pre-push', 'commit-msg <message file>', 'talisman_hook_script pre-commit',
+#     'talisman_hook_script pre-push' or 'talisman_hook_script commit-msg <message file>'
 case "$NAME" in
-pre-commit* | pre-push*) HOOKNAME="${NAME}" ;;
+pre-commit* | pre-push* | commit-msg*) HOOKNAME="${NAME}" ;;
 talisman_hook_script)
 	if [[ $# -gt 0 && $1 =~ pre-push.* ]]; then
 		HOOKNAME="pre-push"
+	elif [[ $# -gt 0 && $1 =~ commit-msg.* ]]; then
+		HOOKNAME="commit-msg"
+		shift
 	fi
 	;;
 *)
@@ -58,7 +61,7 @@ function check_and_upgrade_talisman_binary() {
 }
 
 check_and_upgrade_talisman_binary
-# Here HOOKNAME should be either 'pre-commit' (default) or 'pre-push'
+# Here HOOKNAME should be either 'pre-commit' (default), 'pre-push' or 'commit-msg'
 echo_debug "Firing ${HOOKNAME} hook"
 
 # Don't run talisman checks in a git repo, if we find a .talisman_skip or .talisman_skip.pre-<commit/push> file in the repo
@@ -70,9 +73,30 @@ fi
 DEBUG_OPTS=""
 [[ -n "${TALISMAN_DEBUG}" ]] && DEBUG_OPTS="-d"
 INTERACTIVE=""
-[[ -n "${TALISMAN_INTERACTIVE}" ]] && INTERACTIVE="-i"
+if [[ -n "${TALISMAN_INTERACTIVE}" ]]; then
+	# prompts need a terminal, which CI servers and GUI git clients do not have.
+	# git writes the refs being pushed to the stdin of the pre-push hook, so it is never replaced by the terminal
+	if [[ ! ${HOOKNAME} =~ pre-push.* && -z "${CI}" ]] && (exec </dev/tty) 2>/dev/null; then
+		exec </dev/tty
+		INTERACTIVE="-i"
+	else
+		INTERACTIVE="--no-prompt"
+	fi
+fi
 
-CMD="${TALISMAN_BINARY} ${DEBUG_OPTS} --githook ${HOOKNAME} ${INTERACTIVE}"
+PRE_PUSH_OPTS=""
+if [[ ${HOOKNAME} =~ pre-push.* ]]; then
+	# commits that the remote already has, and the messages of the commits, are only scanned when asked to
+	[[ -n "${TALISMAN_ALL_COMMITS}" ]] && PRE_PUSH_OPTS="${PRE_PUSH_OPTS} --all-commits"
+	[[ -n "${TALISMAN_COMMIT_MESSAGES}" ]] && PRE_PUSH_OPTS="${PRE_PUSH_OPTS} --commit-messages"
+fi
+
+CMD="${TALISMAN_BINARY} ${DEBUG_OPTS} --githook ${HOOKNAME} ${INTERACTIVE}${PRE_PUSH_OPTS}"
 echo_debug "ARGS are $@"
 echo_debug "Executing: ${CMD}"
-${CMD}
+if [[ ${HOOKNAME} =~ commit-msg.* ]]; then
+	# git passes the file holding the commit message as the first argument of the commit-msg hook
+	${CMD} "$1"
+else
+	${CMD}
+fi
@@ -45,3 +45,31 @@ func (mr *MockPromptMockRecorder) Confirm(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockPrompt)(nil).Confirm), arg0)
 }
+
+// Input mocks base method
+func (m *MockPrompt) Input(message, defaultValue string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Input", message, defaultValue)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Input indicates an expected call of Input
+func (mr *MockPromptMockRecorder) Input(message, defaultValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Input", reflect.TypeOf((*MockPrompt)(nil).Input), message, defaultValue)
+}
+
+// Select mocks base method
+func (m *MockPrompt) Select(message string, options []string, defaultOption string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Select", message, options, defaultOption)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Select indicates an expected call of Select
+func (mr *MockPromptMockRecorder) Select(message, options, defaultOption interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockPrompt)(nil).Select), message, options, defaultOption)
+}
@@ -12,7 +12,7 @@ func NewPreCommitHook() *PreCommitHook {
 	return &PreCommitHook{}
 }
 
-func (p *PreCommitHook) GetRepoAdditions() []gitrepo.Addition {
+func (p *PreCommitHook) GetRepoAdditions() ([]gitrepo.Addition, error) {
 	wd, _ := os.Getwd()
 	repo := gitrepo.RepoLocatedAt(wd)
 	return repo.GetDiffForStagedFiles()
@@ -5,6 +5,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"talisman/gitrepo"
+	"talisman/utility"
 )
 
 const (
@@ -14,63 +15,132 @@ const (
 	EmptyTreeSha string = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 )
 
-type PrePushHook struct {
+//PushedRef is a ref that is being pushed, as git describes it on a line of the standard input of the pre-push hook
+type PushedRef struct {
 	localRef, localCommit, remoteRef, remoteCommit string
 }
 
-func NewPrePushHook(localRef, localCommit, remoteRef, remoteCommit string) *PrePushHook {
-	return &PrePushHook{localRef, localCommit, remoteRef, remoteCommit}
+//NewPushedRef returns a PushedRef for a line of the standard input of the pre-push hook
+func NewPushedRef(localRef, localCommit, remoteRef, remoteCommit string) PushedRef {
+	return PushedRef{localRef, localCommit, remoteRef, remoteCommit}
 }
 
-//If the outgoing ref does not exist on the remote, all commits on the local ref will be checked
-//If the outgoing ref already exists, all additions in the range between "localSha" and "remoteSha" will be validated
-func (p *PrePushHook) GetRepoAdditions() []gitrepo.Addition {
-	if p.runningOnDeletedRef() {
-		log.WithFields(log.Fields{
-			"localRef":     p.localRef,
-			"localCommit":  p.localCommit,
-			"remoteRef":    p.remoteRef,
-			"remoteCommit": p.remoteCommit,
-		}).Info("Running on a deleted ref. Nothing to verify as outgoing changes are all deletions.")
-
-		return []gitrepo.Addition{}
-	}
+type PrePushHook struct {
+	refs []PushedRef
+	//allCommits makes the hook scan the commits that are already on a remote as well
+	allCommits bool
+	//commitMessages makes the hook scan the messages of the pushed commits as well
+	commitMessages bool
+}
 
-	if p.runningOnNewRef() {
-		log.WithFields(log.Fields{
-			"localRef":     p.localRef,
-			"localCommit":  p.localCommit,
-			"remoteRef":    p.remoteRef,
-			"remoteCommit": p.remoteCommit,
-		}).Info("Running on a new ref. All changes in the ref will be verified.")
+//NewPrePushHook returns a PrePushHook for the pushed refs, which only scans the commits that no remote-tracking ref reaches, unless allCommits is set
+func NewPrePushHook(allCommits bool, refs ...PushedRef) *PrePushHook {
+	return &PrePushHook{refs: refs, allCommits: allCommits}
+}
 
-		return p.getRepoAdditionsFrom(EmptyTreeSha, p.localCommit)
+//WithCommitMessages makes the hook scan the messages of the pushed commits along with their blobs
+func (p *PrePushHook) WithCommitMessages(commitMessages bool) *PrePushHook {
+	p.commitMessages = commitMessages
+	return p
+}
+
+//GetRepoAdditions returns the blobs that every pushed commit introduces, each of them recording the commits and refs it is part of.
+//Refs that share a commit range, such as a branch and a tag on the same commit, have their additions calculated once.
+func (p *PrePushHook) GetRepoAdditions() ([]gitrepo.Addition, error) {
+	var result []gitrepo.Addition
+	indexes := map[string]int{}
+	additionsOfRange := map[string][]gitrepo.Addition{}
+	for _, ref := range p.refs {
+		oldCommit, newCommit, ok := ref.commitRange()
+		if !ok {
+			continue
+		}
+		commitRange := oldCommit + ".." + newCommit
+		additions, calculated := additionsOfRange[commitRange]
+		if !calculated {
+			var err error
+			if additions, err = p.getRepoAdditionsFrom(oldCommit, newCommit); err != nil {
+				return nil, err
+			}
+			additionsOfRange[commitRange] = additions
+		}
+		for _, addition := range additions {
+			key := additionKey(addition)
+			index, found := indexes[key]
+			if !found {
+				index = len(result)
+				indexes[key] = index
+				result = append(result, addition)
+			} else {
+				result[index].Commits = utility.UniqueItems(append(result[index].Commits, addition.Commits...))
+			}
+			if !contains(result[index].Refs, ref.localRef) {
+				result[index].Refs = append(append([]string{}, result[index].Refs...), ref.localRef)
+			}
+		}
 	}
+	return result, nil
+}
 
-	log.WithFields(log.Fields{
-		"localRef":     p.localRef,
-		"localCommit":  p.localCommit,
-		"remoteRef":    p.remoteRef,
-		"remoteCommit": p.remoteCommit,
-	}).Info("Running on an existing ref. All changes in the commit range will be verified.")
+//commitRange returns the range of commits to verify for the ref.
+//If the outgoing ref does not exist on the remote, all commits on the local ref will be checked, which an empty old commit stands for
+//If the outgoing ref already exists, all additions in the range between "localSha" and "remoteSha" will be validated
+//There is nothing to verify for a deleted ref.
+func (ref PushedRef) commitRange() (string, string, bool) {
+	fields := log.Fields{
+		"localRef":     ref.localRef,
+		"localCommit":  ref.localCommit,
+		"remoteRef":    ref.remoteRef,
+		"remoteCommit": ref.remoteCommit,
+	}
+	if ref.runningOnDeletedRef() {
+		log.WithFields(fields).Info("Running on a deleted ref. Nothing to verify as outgoing changes are all deletions.")
+		return "", "", false
+	}
 
-	return p.getRepoAdditions()
-}
+	if ref.runningOnNewRef() {
+		log.WithFields(fields).Info("Running on a new ref. All commits of the ref will be verified.")
+		return "", ref.localCommit, true
+	}
 
-func (p *PrePushHook) runningOnDeletedRef() bool {
-	return p.localCommit == EmptySha
+	log.WithFields(fields).Info("Running on an existing ref. All changes in the commit range will be verified.")
+	return ref.remoteCommit, ref.localCommit, true
 }
 
-func (p *PrePushHook) runningOnNewRef() bool {
-	return p.remoteCommit == EmptySha
+func (ref PushedRef) runningOnDeletedRef() bool {
+	return ref.localCommit == EmptySha
 }
 
-func (p *PrePushHook) getRepoAdditions() []gitrepo.Addition {
-	return p.getRepoAdditionsFrom(p.remoteCommit, p.localCommit)
+func (ref PushedRef) runningOnNewRef() bool {
+	return ref.remoteCommit == EmptySha
 }
 
-func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) []gitrepo.Addition {
+func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) ([]gitrepo.Addition, error) {
 	wd, _ := os.Getwd()
 	repo := gitrepo.RepoLocatedAt(wd)
-	return repo.AdditionsWithinRange(oldCommit, newCommit)
+	additionsOf, messagesOf := repo.UnpushedCommitAdditions, repo.UnpushedCommitMessages
+	if p.allCommits {
+		additionsOf, messagesOf = repo.CommitAdditionsWithinRange, repo.CommitMessagesWithinRange
+	}
+	additions, err := additionsOf(oldCommit, newCommit)
+	if err != nil || !p.commitMessages {
+		return additions, err
+	}
+	messages, err := messagesOf(oldCommit, newCommit)
+	return append(additions, messages...), err
+}
+
+//additionKey identifies the content of an addition at its path.
+//Commit messages are not read from a blob, so they are told apart by the message they hold.
+func additionKey(addition gitrepo.Addition) string {
+	return string(addition.Path) + "\x00" + addition.Blob + "\x00" + string(addition.Data)
+}
+
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,35 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+)
+
+//ciVariables are the environment variables that continuous integration services set for their builds
+var ciVariables = []string{
+	"CI", "CONTINUOUS_INTEGRATION", "GITHUB_ACTIONS", "GITLAB_CI", "JENKINS_URL", "TEAMCITY_VERSION", "TRAVIS",
+	"CIRCLECI", "BUILDKITE", "TF_BUILD", "BITBUCKET_BUILD_NUMBER", "CODEBUILD_BUILD_ID", "DRONE", "APPVEYOR", "GO_SERVER_URL",
+}
+
+//NonInteractiveReason returns why the user can not be prompted in the current environment, or an empty string if they can.
+//The user can not be prompted on a CI server, or when talisman is not attached to a terminal, such as when a GUI git client runs its hooks.
+func NonInteractiveReason() string {
+	return nonInteractiveReason(os.Getenv, isTerminal(os.Stdin) && isTerminal(os.Stdout))
+}
+
+func nonInteractiveReason(getenv func(string) string, terminal bool) string {
+	for _, variable := range ciVariables {
+		if value := getenv(variable); value != "" && value != "false" && value != "0" {
+			return fmt.Sprintf("%s is set, so talisman is running on a CI server", variable)
+		}
+	}
+	if !terminal {
+		return "talisman is not attached to a terminal"
+	}
+	return ""
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
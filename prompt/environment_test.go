@@ -0,0 +1,20 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldNotPromptOnCIServers(t *testing.T) {
+	env := map[string]string{"GITLAB_CI": "true"}
+
+	assert.Equal(t, "GITLAB_CI is set, so talisman is running on a CI server", nonInteractiveReason(func(name string) string { return env[name] }, true))
+}
+
+func TestShouldNotPromptWithoutATerminal(t *testing.T) {
+	env := map[string]string{"CI": "false"}
+
+	assert.Equal(t, "talisman is not attached to a terminal", nonInteractiveReason(func(name string) string { return env[name] }, false))
+	assert.Equal(t, "", nonInteractiveReason(func(name string) string { return env[name] }, true))
+}
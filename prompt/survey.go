@@ -9,6 +9,8 @@ import (
 
 type Prompt interface {
 	Confirm(string) bool
+	Input(message string, defaultValue string) string
+	Select(message string, options []string, defaultOption string) string
 }
 
 func NewPrompt() Prompt {
@@ -48,3 +50,38 @@ func (p prompt) Confirm(message string) bool {
 
 	return confirmation
 }
+
+//Input asks the user for a line of text, which is the supplied default value if the user just confirms it
+func (p prompt) Input(message string, defaultValue string) string {
+	inputPrompt := &survey.Input{
+		Default: defaultValue,
+		Message: message,
+	}
+
+	answer := defaultValue
+	err := survey.AskOne(inputPrompt, &answer)
+	if err != nil {
+		log.Printf("error occured when getting input from user: %s", err)
+		return defaultValue
+	}
+
+	return answer
+}
+
+//Select asks the user to choose one of the options, which is the supplied default option if the user just confirms it
+func (p prompt) Select(message string, options []string, defaultOption string) string {
+	selectPrompt := &survey.Select{
+		Message: message,
+		Options: options,
+		Default: defaultOption,
+	}
+
+	answer := defaultOption
+	err := survey.AskOne(selectPrompt, &answer)
+	if err != nil {
+		log.Printf("error occured when getting input from user: %s", err)
+		return defaultOption
+	}
+
+	return answer
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	"talisman/gitrepo"
+)
+
+//RangeScan scans the commits of a revision range, as a CI pipeline does for the commits of a pull request
+type RangeScan struct {
+	revisionRange string
+	since         string
+	//commitMessages makes the scan check the messages of the commits as well
+	commitMessages bool
+}
+
+//NewRangeScan returns a RangeScan for the commits of the revision range made after since, either of which may be empty
+func NewRangeScan(revisionRange string, since string, commitMessages bool) *RangeScan {
+	return &RangeScan{revisionRange, since, commitMessages}
+}
+
+//GetRepoAdditions returns the blobs that every commit of the range introduces, each of them recording the commits it is part of
+func (s *RangeScan) GetRepoAdditions() ([]gitrepo.Addition, error) {
+	wd, _ := os.Getwd()
+	repo := gitrepo.RepoLocatedAt(wd)
+	additions, err := repo.RangeCommitAdditions(s.revisionRange, s.since)
+	if err != nil || !s.commitMessages {
+		return additions, err
+	}
+	messages, err := repo.RangeCommitMessages(s.revisionRange, s.since)
+	return append(additions, messages...), err
+}
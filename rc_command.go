@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"talisman/detector"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/utility"
+)
+
+//RCCommandName is the first argument that selects the .talismanrc maintenance subcommands, such as `talisman rc validate`
+const RCCommandName = "rc"
+
+const rcUsage = `usage: talisman rc <command>
+
+commands:
+  validate    report problems in the .talismanrc files of the current repository,
+              the user-global .talismanrc and the organisation policy file
+  prune       remove the fileignoreconfig entries that no longer match any file,
+              or whose checksum no longer matches the staged content
+  update      recalculate the checksums that no longer match the staged content
+  migrate     convert the legacy .talismanignore file into fileignoreconfig entries
+`
+
+//RCCommand carries out the subcommands that maintain the .talismanrc file of a repository
+type RCCommand struct {
+	repo   gitrepo.GitRepo
+	stdout io.Writer
+	prompt prompt.Prompt
+}
+
+//NewRCCommand returns an RCCommand for the repository in the current working directory
+func NewRCCommand(stdout io.Writer) *RCCommand {
+	wd, _ := os.Getwd()
+	return &RCCommand{repo: gitrepo.RepoLocatedAt(wd), stdout: stdout, prompt: prompt.NewPrompt()}
+}
+
+//Run carries out the subcommand named by the first argument and returns the exit status
+func (c *RCCommand) Run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(c.stdout, rcUsage)
+		return CompletedWithErrors
+	}
+	switch args[0] {
+	case "validate":
+		return c.Validate()
+	case "prune":
+		return c.Prune()
+	case "update":
+		return c.Update()
+	case "migrate":
+		return c.Migrate()
+	}
+	fmt.Fprintf(c.stdout, "unknown command %q\n\n%s", args[0], rcUsage)
+	return CompletedWithErrors
+}
+
+//Validate prints every problem found in the .talismanrc file, and fails if any of them makes the file invalid
+func (c *RCCommand) Validate() int {
+	diagnostics := validateTalismanRC(c.repo)
+	if len(diagnostics) == 0 {
+		fmt.Fprintf(c.stdout, "%s is valid\n", detector.DefaultRCFileName)
+		return CompletedSuccessfully
+	}
+	fmt.Fprintln(c.stdout, diagnostics)
+	if diagnostics.HasErrors() {
+		return CompletedWithErrors
+	}
+	return CompletedSuccessfully
+}
+
+//Prune lists the stale fileignoreconfig entries of the .talismanrc files, and removes them once the user confirms it
+func (c *RCCommand) Prune() int {
+	return c.rewriteStaleIgnores(func(stale detector.StaleIgnore) bool { return true },
+		"Remove these entries?",
+		func(fileContents []byte, stale []detector.StaleIgnore) ([]byte, error) {
+			var indexes []int
+			for _, ignore := range stale {
+				indexes = append(indexes, ignore.Index)
+			}
+			return detector.RemoveFileIgnores(fileContents, indexes)
+		})
+}
+
+//Update lists the fileignoreconfig entries whose checksum no longer matches the staged content, and recalculates them once the user confirms it
+func (c *RCCommand) Update() int {
+	return c.rewriteStaleIgnores(func(stale detector.StaleIgnore) bool { return !stale.MatchesNoFile() },
+		"Update the checksums of these entries?",
+		func(fileContents []byte, stale []detector.StaleIgnore) ([]byte, error) {
+			checksums := map[int]string{}
+			for _, ignore := range stale {
+				checksums[ignore.Index] = ignore.Checksum
+			}
+			return detector.UpdateFileIgnoreChecksums(fileContents, checksums)
+		})
+}
+
+//Migrate converts the patterns of the legacy .talismanignore file into fileignoreconfig entries, with the checksums of the staged content.
+//The entries are added to the .talismanrc file once the user confirms the changes, which are shown as a diff.
+func (c *RCCommand) Migrate() int {
+	if !c.repo.CheckIfFileExists(detector.LegacyIgnoreFileName) {
+		fmt.Fprintf(c.stdout, "No %s found\n", detector.LegacyIgnoreFileName)
+		return CompletedSuccessfully
+	}
+	legacyContents, err := c.repo.ReadRepoFile(detector.LegacyIgnoreFileName)
+	if err != nil {
+		fmt.Fprintln(c.stdout, err)
+		return CompletedWithErrors
+	}
+	additions, err := c.repo.IndexedAdditions()
+	if err != nil {
+		fmt.Fprintln(c.stdout, err)
+		return CompletedWithErrors
+	}
+	ignores, unmatched := detector.ReadLegacyIgnores(legacyContents).FileIgnoreConfigs(additions)
+	for _, pattern := range unmatched {
+		fmt.Fprintf(c.stdout, "Skipping %s, as it matches no tracked file\n", pattern)
+	}
+	if len(ignores) == 0 {
+		fmt.Fprintf(c.stdout, "Nothing to migrate from %s\n", detector.LegacyIgnoreFileName)
+		return CompletedSuccessfully
+	}
+	fileContents, err := c.repo.ReadRepoFileOrNothing(detector.DefaultRCFileName)
+	if err != nil {
+		fmt.Fprintln(c.stdout, err)
+		return CompletedWithErrors
+	}
+	migrated, err := detector.AppendFileIgnores(fileContents, ignores)
+	if err != nil {
+		fmt.Fprintf(c.stdout, "%s: %v\n", detector.DefaultRCFileName, err)
+		return CompletedWithErrors
+	}
+	fmt.Fprintf(c.stdout, "--- %s\n+++ %s\n", detector.DefaultRCFileName, detector.DefaultRCFileName)
+	for _, line := range utility.LineDiff(string(fileContents), string(migrated)) {
+		fmt.Fprintln(c.stdout, line)
+	}
+	if !c.prompt.Confirm(fmt.Sprintf("Write these changes to %s?", detector.DefaultRCFileName)) {
+		fmt.Fprintln(c.stdout, "No changes made")
+		return CompletedSuccessfully
+	}
+	if err := c.repo.WriteRepoFile(detector.DefaultRCFileName, migrated); err != nil {
+		fmt.Fprintf(c.stdout, "%s: %v\n", detector.DefaultRCFileName, err)
+		return CompletedWithErrors
+	}
+	fmt.Fprintf(c.stdout, "Updated %s. %s is no longer read, and can be removed\n", detector.DefaultRCFileName, detector.LegacyIgnoreFileName)
+	return CompletedSuccessfully
+}
+
+//rewriteStaleIgnores finds the selected stale entries in every .talismanrc file of the repository, and rewrites the files once the user confirms it.
+//Checksums are compared with the staged content, just like talisman --checksum calculates them.
+func (c *RCCommand) rewriteStaleIgnores(selected func(detector.StaleIgnore) bool, question string, rewrite func([]byte, []detector.StaleIgnore) ([]byte, error)) int {
+	additions, err := c.repo.IndexedAdditions()
+	if err != nil {
+		fmt.Fprintln(c.stdout, err)
+		return CompletedWithErrors
+	}
+	cc := detector.NewChecksumCompare(additions, detector.TalismanRCIgnore{})
+	rcFiles := append([]string{detector.DefaultRCFileName}, nestedRCFiles(c.repo)...)
+	staleIgnores := map[string][]detector.StaleIgnore{}
+	contents := map[string][]byte{}
+	for _, rcFile := range rcFiles {
+		fileContents, err := c.repo.ReadRepoFileOrNothing(rcFile)
+		if err != nil {
+			fmt.Fprintln(c.stdout, err)
+			return CompletedWithErrors
+		}
+		config := detector.NewTalismanRCIgnore(fileContents)
+		if rcFile != detector.DefaultRCFileName {
+			config.Directory = path.Dir(rcFile)
+		}
+		for _, stale := range cc.StaleIgnores(config) {
+			if selected(stale) {
+				staleIgnores[rcFile] = append(staleIgnores[rcFile], stale)
+				fmt.Fprintf(c.stdout, "%s: %s\n", rcFile, stale)
+			}
+		}
+		contents[rcFile] = fileContents
+	}
+	if len(staleIgnores) == 0 {
+		fmt.Fprintln(c.stdout, "No stale fileignoreconfig entries found")
+		return CompletedSuccessfully
+	}
+	if !c.prompt.Confirm(question) {
+		fmt.Fprintln(c.stdout, "No changes made")
+		return CompletedSuccessfully
+	}
+	status := CompletedSuccessfully
+	for _, rcFile := range rcFiles {
+		if len(staleIgnores[rcFile]) == 0 {
+			continue
+		}
+		rewritten, err := rewrite(contents[rcFile], staleIgnores[rcFile])
+		if err == nil {
+			err = c.repo.WriteRepoFile(rcFile, rewritten)
+		}
+		if err != nil {
+			fmt.Fprintf(c.stdout, "%s: %v\n", rcFile, err)
+			status = CompletedWithErrors
+			continue
+		}
+		fmt.Fprintf(c.stdout, "Updated %s\n", rcFile)
+	}
+	return status
+}
+
+//validateTalismanRC checks the .talismanrc files of the repository against the files tracked in it and the known scopes
+func validateTalismanRC(repo gitrepo.GitRepo) detector.Diagnostics {
+	fileContents, err := repo.ReadRepoFileOrNothing(detector.DefaultRCFileName)
+	if err != nil {
+		return detector.Diagnostics{{Severity: detector.SeverityError, Message: err.Error()}}
+	}
+	trackedFiles, err := repo.IndexedFilePaths()
+	if err != nil {
+		return detector.Diagnostics{{Severity: detector.SeverityError, Message: err.Error()}}
+	}
+	diagnostics := detector.ValidateTalismanRC(fileContents, trackedFiles, getScopeConfig())
+	reasonRequired := detector.ReadLayeredConfig(detector.NewTalismanRCIgnore(fileContents)).RequireIgnoreReason
+	if reasonRequired {
+		diagnostics = append(diagnostics, detector.MissingReasons("", fileContents)...)
+	}
+	for _, rcFile := range nestedRCFiles(repo) {
+		fileContents, err := repo.ReadRepoFileOrNothing(rcFile)
+		if err != nil {
+			diagnostics = append(diagnostics, detector.Diagnostic{Severity: detector.SeverityError, Message: err.Error(), File: rcFile})
+			continue
+		}
+		directory := path.Dir(rcFile) + "/"
+		var filesInDirectory []string
+		for _, trackedFile := range trackedFiles {
+			if strings.HasPrefix(trackedFile, directory) {
+				filesInDirectory = append(filesInDirectory, strings.TrimPrefix(trackedFile, directory))
+			}
+		}
+		diagnostics = append(diagnostics, detector.ValidateNestedTalismanRC(rcFile, fileContents, filesInDirectory, getScopeConfig())...)
+		if reasonRequired {
+			diagnostics = append(diagnostics, detector.MissingReasons(rcFile, fileContents)...)
+		}
+	}
+	return append(diagnostics, validateConfigLayers()...)
+}
+
+//validateConfigLayers checks the organisation policy file and the user-global .talismanrc, if they exist
+func validateConfigLayers() detector.Diagnostics {
+	layers := []struct {
+		filePath string
+		validate func(string, []byte, map[string][]string) detector.Diagnostics
+	}{
+		{detector.PolicyFilePath(), detector.ValidatePolicyFile},
+		{detector.UserRCFilePath(), detector.ValidateUserRCFile},
+	}
+	var diagnostics detector.Diagnostics
+	for _, layer := range layers {
+		if layer.filePath == "" {
+			continue
+		}
+		fileContents, err := ioutil.ReadFile(layer.filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, detector.Diagnostic{Severity: detector.SeverityError, Message: err.Error(), File: layer.filePath})
+			continue
+		}
+		diagnostics = append(diagnostics, layer.validate(layer.filePath, fileContents, getScopeConfig())...)
+	}
+	return diagnostics
+}
+
+//nestedRCFiles returns the paths of the .talismanrc files in the subdirectories of the repository
+func nestedRCFiles(repo gitrepo.GitRepo) []string {
+	rcFiles, err := repo.FilesNamed(detector.DefaultRCFileName)
+	if err != nil {
+		log.Printf("error looking for nested %s files: %v", detector.DefaultRCFileName, err)
+		return nil
+	}
+	var result []string
+	for _, rcFile := range rcFiles {
+		if path.Dir(rcFile) != "." {
+			result = append(result, rcFile)
+		}
+	}
+	return result
+}
@@ -24,33 +24,55 @@ const (
 
 //Runner represents a single run of the validations for a given commit range
 type Runner struct {
-	additions []gitrepo.Addition
-	results   *detector.DetectionResults
+	additions    []gitrepo.Addition
+	results      *detector.DetectionResults
+	jobs         int
+	chain        *detector.Chain
+	//messageChain tests commit messages, which have no file name or size worth checking
+	messageChain *detector.Chain
 }
 
-//NewRunner returns a new Runner.
-func NewRunner(additions []gitrepo.Addition) *Runner {
+//NewRunner returns a new Runner that scans up to jobs files concurrently.
+func NewRunner(additions []gitrepo.Addition, jobs int) *Runner {
 	return &Runner{
-		additions: additions,
-		results:   detector.NewDetectionResults(),
+		additions:    additions,
+		results:      detector.NewDetectionResults(),
+		jobs:         jobs,
+		chain:        detector.DefaultChain(jobs),
+		messageChain: detector.ContentChain(jobs),
 	}
 }
 
 //RunWithoutErrors will validate the commit range for errors and return either COMPLETED_SUCCESSFULLY or COMPLETED_WITH_ERRORS
 func (r *Runner) RunWithoutErrors(promptContext prompt.PromptContext) int {
+	wd, _ := os.Getwd()
+	if diagnostics := validateTalismanRC(gitrepo.RepoLocatedAt(wd)); diagnostics.HasErrors() {
+		fmt.Fprintf(os.Stderr, "\n\x1b[1m\x1b[31mTalisman cannot run, as its configuration is invalid:\x1b[0m\x1b[0m\n%s\n", diagnostics)
+		fmt.Fprintf(os.Stderr, "\nRun 'talisman rc validate' after fixing it to check for any other problems.\n")
+		return CompletedWithErrors
+	}
 	r.doRun()
 	r.printReport(promptContext)
 	return r.exitStatus()
 }
 
-//Scan scans git commit history for potential secrets and returns 0 or 1 as exit code
-func (r *Runner) Scan(reportDirectory string) int {
+//Scan scans git commit history for potential secrets and returns 0 or 1 as exit code.
+//The messages of the commits are scanned as well if commitMessages is set.
+func (r *Runner) Scan(reportDirectory string, commitMessages bool) int {
 
 	fmt.Printf("\n\n")
 	utility.CreateArt("Running Scan..")
 	additions := scanner.GetAdditions()
+	if commitMessages {
+		messages, err := gitrepo.RepoLocatedAt(".").AllCommitMessages()
+		if err != nil {
+			log.Printf("error while reading commit messages: %v", err)
+			return CompletedWithErrors
+		}
+		additions = append(additions, messages...)
+	}
 	ignores := detector.TalismanRCIgnore{}
-	detector.DefaultChain().Test(additions, ignores, r.results)
+	r.test(additions, ignores)
 	reportsPath, err := report.GenerateReport(r.results, reportDirectory)
 	if err != nil {
 		log.Printf("error while generating report: %v", err)
@@ -74,16 +96,51 @@ func (r *Runner) RunChecksumCalculator(fileNamePatterns []string) int {
 }
 
 func (r *Runner) doRun() {
-	rcConfigIgnores := detector.ReadConfigFromRCFile(readRepoFile())
+	wd, _ := os.Getwd()
 	scopeMap := getScopeConfig()
-	additionsToScan := detector.IgnoreAdditionsByScope(r.additions, rcConfigIgnores, scopeMap);
-	detector.DefaultChain().Test(additionsToScan, rcConfigIgnores, r.results)
+	rcConfigIgnores := detector.ReadLayeredConfig(detector.ReadConfigFromRCFiles(readRepoFile(), nestedRCFiles(gitrepo.RepoLocatedAt(wd)))).WithScopes(scopeMap)
+	if indexed, err := gitrepo.RepoLocatedAt(wd).IndexedAdditions(); err == nil {
+		rcConfigIgnores = rcConfigIgnores.WithIndexedFiles(indexed)
+	} else {
+		log.Printf("error listing the files in the index: %v", err)
+	}
+	additionsToScan := detector.IgnoreAdditionsByScope(r.additions, rcConfigIgnores, scopeMap)
+	r.test(additionsToScan, rcConfigIgnores)
+	detector.NewChecksumCompare(additionsToScan, rcConfigIgnores).ReportLegacyChecksums(r.results)
+	rcConfigIgnores.ReportExpiries(additionsToScan, r.results)
+	detector.ReportLegacyIgnoreFile(gitrepo.RepoLocatedAt(wd).CheckIfFileExists, r.results)
+	r.results.AttributeRefs(r.additions)
+	r.results.ChecksumContentOf(r.additions)
+	r.results.SuggestExpiry(rcConfigIgnores.ExpiryConfig.DefaultExpiry())
+	r.results.RequireReason(rcConfigIgnores.RequireIgnoreReason)
+}
+
+//test tests the files with the chain of detectors, and the commit messages with the content detectors only
+func (r *Runner) test(additions []gitrepo.Addition, ignores detector.TalismanRCIgnore) {
+	var files, messages []gitrepo.Addition
+	for _, addition := range additions {
+		if addition.CommitMessage {
+			messages = append(messages, addition)
+		} else {
+			files = append(files, addition)
+		}
+	}
+	r.chain.Test(files, ignores, r.results)
+	if len(messages) > 0 {
+		r.messageChain.Test(messages, ignores, r.results)
+	}
 }
 
 func getScopeConfig() map[string][]string {
 	scopeConfig := map[string][]string{
-		"node": {"yarn.lock", "package-lock.json", "node_modules/"},
-		"go":   {"makefile", "go.mod", "go.sum", "Gopkg.toml", "Gopkg.lock", "glide.yaml", "glide.lock", "vendor/"},
+		"node":      {"yarn.lock", "package-lock.json", "node_modules/"},
+		"go":        {"makefile", "go.mod", "go.sum", "Gopkg.toml", "Gopkg.lock", "glide.yaml", "glide.lock", "vendor/"},
+		"python":    {"poetry.lock", "Pipfile.lock", "pdm.lock", "venv/", ".venv/"},
+		"java":      {"gradle.lockfile", "settings-gradle.lockfile", "gradle/dependency-locks/", "gradle/verification-metadata.xml"},
+		"ruby":      {"Gemfile.lock", "vendor/bundle/", ".bundle/"},
+		"rust":      {"Cargo.lock"},
+		"dotnet":    {"packages.lock.json", "project.assets.json", "paket.lock"},
+		"terraform": {".terraform.lock.hcl", ".terraform/"},
 	}
 	return scopeConfig
 }
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"io"
 	"log"
 	"os/exec"
 	"strings"
@@ -15,13 +16,13 @@ type BlobsInCommits struct {
 // GetAdditions will get all the additions for entire git history
 func GetAdditions() []gitrepo.Addition {
 	blobsInCommits := getBlobsInCommit()
+	repo := gitrepo.RepoLocatedAt(".")
 	var additions []gitrepo.Addition
 	for blob := range blobsInCommits.commits {
 		objectDetails := strings.Split(blob, "\t")
 		objectHash := objectDetails[0]
-		data := getData(objectHash)
 		filePath := objectDetails[1]
-		newAddition := gitrepo.NewScannerAddition(filePath, blobsInCommits.commits[blob], data)
+		newAddition := gitrepo.NewLazyAddition(filePath, blobsInCommits.commits[blob], openData(repo, objectHash))
 		additions = append(additions, newAddition)
 	}
 	return additions
@@ -42,7 +43,7 @@ func getBlobsInCommit() BlobsInCommits {
 
 func putBlobsInChannel(commit string, result chan []string) {
 	if commit != "" {
-		blobDetailsBytes, _ := exec.Command("git", "ls-tree", "-r", commit).CombinedOutput()
+		blobDetailsBytes, _ := exec.Command("git", "ls-tree", "-r", commit).Output()
 		blobDetailsList := strings.Split(string(blobDetailsBytes), "\n")
 		blobDetailsList = append(blobDetailsList, commit)
 		result <- blobDetailsList
@@ -52,16 +53,27 @@ func putBlobsInChannel(commit string, result chan []string) {
 func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan []string) {
 	blobs := <-result
 	commit := blobs[len(blobs)-1]
-	for _, blob := range blobs[:len(blobs)] {
-		if blob != "" && blob != commit {
-			blobDetailsString := strings.Split(blob, " ")
-			blobDetails := strings.Split(blobDetailsString[2], "	")
-			blobHash := blobDetails[0] + "\t" + blobDetails[1]
+	for _, blob := range blobs[:len(blobs)-1] {
+		if blobHash, ok := parseBlob(blob); ok {
 			blobsInCommits.commits[blobHash] = append(blobsInCommits.commits[blobHash], commit)
 		}
 	}
 }
 
+//parseBlob turns a line of ls-tree output, such as "100644 blob <hash>\t<path>", into "<hash>\t<path>".
+//Lines that don't describe a blob, such as those of submodules or error messages, are skipped.
+func parseBlob(blob string) (string, bool) {
+	objectAndPath := strings.SplitN(blob, "\t", 2)
+	if len(objectAndPath) != 2 {
+		return "", false
+	}
+	objectDetails := strings.Fields(objectAndPath[0])
+	if len(objectDetails) != 3 || objectDetails[1] != "blob" {
+		return "", false
+	}
+	return objectDetails[2] + "\t" + objectAndPath[1], true
+}
+
 func getAllCommits() []string {
 	out, err := exec.Command("git", "log", "--all", "--pretty=%H").CombinedOutput()
 	if err != nil {
@@ -70,9 +82,10 @@ func getAllCommits() []string {
 	return strings.Split(string(out), "\n")
 }
 
-func getData(objectHash string) []byte {
-	out, _ := exec.Command("git", "cat-file", "-p", objectHash).CombinedOutput()
-	return out
+func openData(repo gitrepo.GitRepo, objectHash string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return repo.OpenBlob(objectHash)
+	}
 }
 
 func newBlobsInCommit() BlobsInCommits {
@@ -0,0 +1,27 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldCollectBlobsOfEveryCommit(t *testing.T) {
+	blobsInCommits := newBlobsInCommit()
+	result := make(chan []string, 1)
+	result <- []string{"100644 blob 0123abcd\tfolder/file name.txt", "commit1"}
+
+	getBlobsFromChannel(blobsInCommits, result)
+
+	assert.Equal(t, map[string][]string{"0123abcd\tfolder/file name.txt": {"commit1"}}, blobsInCommits.commits)
+}
+
+func TestShouldSkipLinesThatAreNotBlobs(t *testing.T) {
+	blobsInCommits := newBlobsInCommit()
+	result := make(chan []string, 1)
+	result <- []string{"fatal: not a tree object", "160000 commit 89abcdef\tsubmodule", "", "commit1"}
+
+	getBlobsFromChannel(blobsInCommits, result)
+
+	assert.Empty(t, blobsInCommits.commits)
+}
@@ -11,7 +11,9 @@ import (
 	"io"
 	"os"
 	"strings"
+	"talisman/detector"
 	"talisman/gitrepo"
+	"talisman/report"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -28,6 +30,12 @@ var (
 	reportdirectory string
 	scanWithHtml    bool
 	interactive     bool
+	noPrompt        bool
+	allCommits      bool
+	commitMessages  bool
+	revisionRange   string
+	since           string
+	jobs            int
 )
 
 const (
@@ -35,6 +43,8 @@ const (
 	PrePush = "pre-push"
 	//PreCommit : Const for name of of pre-commit hook
 	PreCommit = "pre-commit"
+	//CommitMsg : Const for name of commit-msg hook
+	CommitMsg = "commit-msg"
 )
 
 func init() {
@@ -49,6 +59,12 @@ type options struct {
 	checksum        string
 	reportdirectory string
 	scanWithHtml    bool
+	allCommits      bool
+	commitMessages  bool
+	messageFile     string
+	revisionRange   string
+	since           string
+	jobs            int
 }
 
 //Logger is the default log device, set to emit at the Error level by default
@@ -56,12 +72,18 @@ func main() {
 	flag.BoolVarP(&fdebug, "debug", "d", false, "enable debug mode (warning: very verbose)")
 	flag.BoolVarP(&showVersion, "version", "v", false, "show current version of talisman")
 	flag.StringVarP(&pattern, "pattern", "p", "", "pattern (glob-like) of files to scan (ignores githooks)")
-	flag.StringVarP(&githook, "githook", "g", PrePush, "either pre-push or pre-commit")
+	flag.StringVarP(&githook, "githook", "g", PrePush, "either pre-push, pre-commit or commit-msg, which is followed by the file holding the commit message")
 	flag.BoolVarP(&scan, "scan", "s", false, "scanner scans the git commit history for potential secrets")
 	flag.StringVarP(&checksum, "checksum", "c", "", "checksum calculator calculates checksum and suggests .talsimarc format")
 	flag.StringVarP(&reportdirectory, "reportdirectory", "r", "", "directory where the scan reports will be stored")
 	flag.BoolVarP(&scanWithHtml, "scanWithHtml", "w", false, "generate html report (**Make sure you have installed talisman_html_report to use this, as mentioned in Readme**)")
 	flag.BoolVarP(&interactive, "interactive", "i", false, "to be interactive or not")
+	flag.BoolVar(&noPrompt, "no-prompt", false, "never prompt, even when interactive, and print the suggested .talismanrc entries instead")
+	flag.BoolVar(&allCommits, "all-commits", false, "scan every pushed commit, including those that are already on a remote (pre-push only)")
+	flag.BoolVar(&commitMessages, "commit-messages", false, "scan the messages of the pushed commits, or of every commit when scanning the history or a range")
+	flag.StringVar(&revisionRange, "range", "", "scan the commits of a git revision range, such as origin/main..HEAD, and store a report like --scan does")
+	flag.StringVar(&since, "since", "", "scan only the commits made after a date, such as 2024-01-31 or \"2 weeks ago\", of the range or of HEAD")
+	flag.IntVarP(&jobs, "jobs", "j", detector.DefaultJobs, "number of files to scan concurrently")
 
 	flag.Parse()
 
@@ -70,14 +92,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	if flag.Arg(0) == RCCommandName {
+		os.Exit(NewRCCommand(os.Stdout).Run(flag.Args()[1:]))
+	}
+
 	if flag.NFlag() == 0 {
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
 
 	if githook != "" {
-		if !(githook == PreCommit || githook == PrePush) {
-			fmt.Println(fmt.Errorf("githook should be %s or %s, but got %s", PreCommit, PrePush, githook))
+		if !(githook == PreCommit || githook == PrePush || githook == CommitMsg) {
+			fmt.Println(fmt.Errorf("githook should be %s, %s or %s, but got %s", PreCommit, PrePush, CommitMsg, githook))
+			os.Exit(1)
+		}
+		if githook == CommitMsg && flag.NArg() != 1 {
+			fmt.Println(fmt.Errorf("githook %s should be followed by the file holding the commit message", CommitMsg))
 			os.Exit(1)
 		}
 	}
@@ -90,11 +120,23 @@ func main() {
 		checksum:        checksum,
 		reportdirectory: reportdirectory,
 		scanWithHtml:    scanWithHtml,
+		allCommits:      allCommits,
+		commitMessages:  commitMessages,
+		messageFile:     flag.Arg(0),
+		revisionRange:   revisionRange,
+		since:           since,
+		jobs:            jobs,
 
 	}
 
+	if interactive && !noPrompt {
+		if reason := prompt.NonInteractiveReason(); reason != "" {
+			fmt.Fprintf(os.Stderr, "Not prompting, as %s. Printing the suggested .talismanrc entries instead.\n", reason)
+			noPrompt = true
+		}
+	}
 	prompter := prompt.NewPrompt()
-	promptContext := prompt.NewPromptContext(interactive, prompter)
+	promptContext := prompt.NewPromptContext(interactive && !noPrompt, prompter)
 
 	os.Exit(run(os.Stdin, _options, promptContext))
 }
@@ -111,37 +153,70 @@ func run(stdin io.Reader, _options options, promptContext prompt.PromptContext)
 	}
 
 	var additions []gitrepo.Addition
+	var err error
+	reportDirectory := _options.reportdirectory
 	if _options.checksum != "" {
 		log.Infof("Running %s patterns against checksum calculator", _options.checksum)
-		return NewRunner(make([]gitrepo.Addition, 0)).RunChecksumCalculator(strings.Fields(_options.checksum))
+		return NewRunner(make([]gitrepo.Addition, 0), _options.jobs).RunChecksumCalculator(strings.Fields(_options.checksum))
+	} else if _options.revisionRange != "" || _options.since != "" {
+		log.Infof("Running scanner on the commits of range %q since %q", _options.revisionRange, _options.since)
+		rangeScan := NewRangeScan(_options.revisionRange, _options.since, _options.commitMessages)
+		additions, err = rangeScan.GetRepoAdditions()
+		if _options.scanWithHtml {
+			reportDirectory = "talisman_html_report"
+		} else if reportDirectory == "" {
+			reportDirectory = "."
+		}
 	} else if _options.scan {
 		log.Infof("Running scanner")
-		return NewRunner(make([]gitrepo.Addition, 0)).Scan(_options.reportdirectory)
+		return NewRunner(make([]gitrepo.Addition, 0), _options.jobs).Scan(_options.reportdirectory, _options.commitMessages)
 	} else if _options.scanWithHtml {
 		log.Infof("Running scanner with html report")
-		return NewRunner(make([]gitrepo.Addition, 0)).Scan("talisman_html_report")
+		return NewRunner(make([]gitrepo.Addition, 0), _options.jobs).Scan("talisman_html_report", _options.commitMessages)
 	} else if _options.pattern != "" {
 		log.Infof("Running %s pattern", _options.pattern)
 		directoryHook := NewDirectoryHook()
 		additions = directoryHook.GetFilesFromDirectory(_options.pattern)
+	} else if _options.githook == CommitMsg {
+		log.Infof("Running %s hook", _options.githook)
+		commitMsgHook := NewCommitMsgHook(_options.messageFile)
+		additions, err = commitMsgHook.GetRepoAdditions()
 	} else if _options.githook == PreCommit {
 		log.Infof("Running %s hook", _options.githook)
 		preCommitHook := NewPreCommitHook()
-		additions = preCommitHook.GetRepoAdditions()
+		additions, err = preCommitHook.GetRepoAdditions()
 	} else {
 		log.Infof("Running %s hook", _options.githook)
-		prePushHook := NewPrePushHook(readRefAndSha(stdin))
-		additions = prePushHook.GetRepoAdditions()
+		prePushHook := NewPrePushHook(_options.allCommits, readRefsAndShas(stdin)...).WithCommitMessages(_options.commitMessages)
+		additions, err = prePushHook.GetRepoAdditions()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n\x1b[1m\x1b[31mTalisman cannot run, as the changes to scan could not be read:\x1b[0m\x1b[0m\n%v\n", err)
+		return CompletedWithErrors
 	}
 
-	return NewRunner(additions).RunWithoutErrors(promptContext)
+	runner := NewRunner(additions, _options.jobs)
+	exitStatus := runner.RunWithoutErrors(promptContext)
+	if reportDirectory != "" {
+		if reportsPath, err := report.GenerateReport(runner.results, reportDirectory); err != nil {
+			log.Errorf("error while generating report: %v", err)
+		} else {
+			fmt.Printf("\nPlease check '%s' folder for the talisman report\n", reportsPath)
+		}
+	}
+	return exitStatus
 }
 
-func readRefAndSha(file io.Reader) (string, string, string, string) {
-	text, _ := bufio.NewReader(file).ReadString('\n')
-	refsAndShas := strings.Split(strings.Trim(string(text), "\n"), " ")
-	if len(refsAndShas) < 4 {
-		return EmptySha, EmptySha, "", ""
+//readRefsAndShas reads every "<local ref> <local sha> <remote ref> <remote sha>" line that git passes to the pre-push hook
+func readRefsAndShas(file io.Reader) []PushedRef {
+	var refs []PushedRef
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		refsAndShas := strings.Fields(scanner.Text())
+		if len(refsAndShas) < 4 {
+			continue
+		}
+		refs = append(refs, NewPushedRef(refsAndShas[0], refsAndShas[1], refsAndShas[2], refsAndShas[3]))
 	}
-	return refsAndShas[0], refsAndShas[1], refsAndShas[2], refsAndShas[3]
+	return refs
 }
@@ -3,6 +3,9 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"strings"
+	"talisman/detector"
+	"talisman/gitrepo"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +21,45 @@ func TestParsingShasFromStdIn(t *testing.T) {
 	file.WriteString("localRef localSha remoteRef remoteSha")
 	file.Seek(0, 0)
 
-	_, oldSha, _, newSha := readRefAndSha(file)
-	assert.Equal(t, "localSha", oldSha, "oldSha did not equal 'localSha', got: %s", oldSha)
-	assert.Equal(t, "remoteSha", newSha, "newSha did not equal 'remoteSha', got: %s", newSha)
+	refs := readRefsAndShas(file)
+	assert.Equal(t, []PushedRef{NewPushedRef("localRef", "localSha", "remoteRef", "remoteSha")}, refs)
+}
+
+func TestParsingEveryRefFromStdIn(t *testing.T) {
+	stdin := strings.NewReader("refs/heads/main aaa refs/heads/main bbb\n\nrefs/tags/v1 ccc refs/tags/v1 0000000000000000000000000000000000000000\n")
+
+	refs := readRefsAndShas(stdin)
+	assert.Equal(t, []PushedRef{
+		NewPushedRef("refs/heads/main", "aaa", "refs/heads/main", "bbb"),
+		NewPushedRef("refs/tags/v1", "ccc", "refs/tags/v1", EmptySha),
+	}, refs)
+}
+
+func TestCommitMessagesLeaveOutTheLinesBelowTheScissorsLine(t *testing.T) {
+	message := "Add a feature\n\n# Please enter the commit message\n# ------------------------ >8 ------------------------\n# Do not modify or remove the line above.\n+password=diffcontent\n"
+
+	assert.Equal(t, "Add a feature\n\n# Please enter the commit message\n", string(withoutScissoredLines([]byte(message))))
+	assert.Equal(t, "Add a feature\n", string(withoutScissoredLines([]byte("Add a feature\n"))))
+}
+
+type recordingDetector struct {
+	tested []gitrepo.Addition
+}
+
+func (d *recordingDetector) Test(additions []gitrepo.Addition, ignoreConfig detector.TalismanRCIgnore, result *detector.DetectionResults) {
+	d.tested = append(d.tested, additions...)
+}
+
+func TestCommitMessagesAreOnlyTestedByTheMessageChain(t *testing.T) {
+	file := gitrepo.NewAddition("safe.txt", []byte("nothing to see here"))
+	message := gitrepo.NewCommitMessageAddition([]byte("Set password=somepassword"), []string{"abc"})
+	files, messages := &recordingDetector{}, &recordingDetector{}
+	runner := NewRunner(nil, 1)
+	runner.chain = detector.NewChain().AddDetector(files)
+	runner.messageChain = detector.NewChain().AddDetector(messages)
+
+	runner.test([]gitrepo.Addition{file, message}, detector.TalismanRCIgnore{})
+
+	assert.Equal(t, []gitrepo.Addition{file}, files.tested)
+	assert.Equal(t, []gitrepo.Addition{message}, messages.tested)
 }
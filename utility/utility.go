@@ -27,13 +27,26 @@ func UniqueItems(stringSlice []string) []string {
 
 //CollectiveSHA256Hash return collective sha256 hash of the passed paths
 func CollectiveSHA256Hash(paths []string) string {
+	return CollectiveSHA256HashIn("", paths)
+}
+
+//CollectiveSHA256HashIn return collective sha256 hash of the passed paths, which are relative to the passed directory
+func CollectiveSHA256HashIn(directory string, paths []string) string {
+	return CollectiveSHA256HashOf(paths, func(filePath string) ([]byte, error) {
+		return ioutil.ReadFile(path.Join(directory, filePath))
+	})
+}
+
+//CollectiveSHA256HashOf return collective sha256 hash of the passed paths, reading the content of every path with the passed function.
+//Content that can't be read is hashed as empty, just like a missing file.
+func CollectiveSHA256HashOf(paths []string, read func(filePath string) ([]byte, error)) string {
 	var finHash = ""
-	for _, path := range paths {
+	for _, filePath := range paths {
 		sbyte := []byte(finHash)
 		concatBytes := hashByte(&sbyte)
-		nameByte := []byte(path)
+		nameByte := []byte(filePath)
 		nameHash := hashByte(&nameByte)
-		fileBytes, _ := ioutil.ReadFile(path)
+		fileBytes, _ := read(filePath)
 		fileHash := hashByte(&fileBytes)
 		finHash = concatBytes + fileHash + nameHash
 	}